@@ -2,9 +2,15 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"math"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/fatih/color"
@@ -12,15 +18,19 @@ import (
 
 	"github.com/L-P/goldutil/goldsrc"
 	"github.com/L-P/goldutil/goldsrc/bsp"
+	"github.com/L-P/goldutil/goldsrc/bsp/mesh"
+	"github.com/L-P/goldutil/goldsrc/bsp/render"
+	"github.com/L-P/goldutil/goldsrc/modfs"
+	"github.com/L-P/goldutil/goldsrc/wad"
 )
 
 func doBSPRemapMaterials(ctx context.Context, cmd *cli.Command) error {
-	source, err := goldsrc.LoadMaterialsFromFile(cmd.String("original-materials"))
+	source, err := loadMaterials(cmd, cmd.String("original-materials"))
 	if err != nil {
 		return fmt.Errorf("unable to load original-materials: %w", err)
 	}
 
-	replacement, err := goldsrc.LoadMaterialsFromFile(cmd.String("replacement-materials"))
+	replacement, err := loadMaterials(cmd, cmd.String("replacement-materials"))
 	if err != nil {
 		return fmt.Errorf("unable to load replacement-materials: %w", err)
 	}
@@ -29,7 +39,7 @@ func doBSPRemapMaterials(ctx context.Context, cmd *cli.Command) error {
 		return errors.New("no materials in source or replacement list")
 	}
 
-	bsp, err := bsp.LoadFromFile(cmd.Args().Get(0))
+	bsp, err := loadBSP(cmd, cmd.Args().Get(0))
 	if err != nil {
 		return fmt.Errorf("unable to load BSP: %w", err)
 	}
@@ -73,7 +83,7 @@ func doBSPRemapMaterials(ctx context.Context, cmd *cli.Command) error {
 }
 
 func doBSPEntities(ctx context.Context, cmd *cli.Command) error {
-	bsp, err := bsp.LoadFromFile(cmd.Args().Get(0))
+	bsp, err := loadBSP(cmd, cmd.Args().Get(0))
 	if err != nil {
 		return fmt.Errorf("unable to load BSP: %w", err)
 	}
@@ -83,8 +93,89 @@ func doBSPEntities(ctx context.Context, cmd *cli.Command) error {
 	return nil
 }
 
+func doBSPEntitiesExport(ctx context.Context, cmd *cli.Command) error {
+	path := cmd.Args().Get(0)
+	if path == "" {
+		return errors.New("expected one argument: the .bsp to export entities from")
+	}
+
+	bsp, err := loadBSP(cmd, path)
+	if err != nil {
+		return fmt.Errorf("unable to load BSP: %w", err)
+	}
+
+	destPath := cmd.String("out")
+	if destPath == "" {
+		diskPath, err := inputPath(cmd, path)
+		if err != nil {
+			return fmt.Errorf("unable to write entities next to the BSP, use --out: %w", err)
+		}
+		destPath = strings.TrimSuffix(diskPath, filepath.Ext(diskPath)) + ".ent"
+	}
+
+	if err := os.WriteFile(destPath, bsp.EntitiesText(), 0600); err != nil {
+		return fmt.Errorf("unable to write entities: %w", err)
+	}
+
+	return nil
+}
+
+func doBSPEntitiesImport(ctx context.Context, cmd *cli.Command) error {
+	path := cmd.Args().Get(0)
+	if path == "" {
+		return errors.New("expected one argument: the .bsp to import entities into")
+	}
+
+	bsp, err := loadBSP(cmd, path)
+	if err != nil {
+		return fmt.Errorf("unable to load BSP: %w", err)
+	}
+
+	entPath := cmd.String("ent")
+	if entPath == "" {
+		diskPath, err := inputPath(cmd, path)
+		if err != nil {
+			return fmt.Errorf("unable to read entities next to the BSP, use --ent: %w", err)
+		}
+		entPath = strings.TrimSuffix(diskPath, filepath.Ext(diskPath)) + ".ent"
+	}
+
+	f, err := os.Open(entPath)
+	if err != nil {
+		return fmt.Errorf("unable to open entities for reading: %w", err)
+	}
+	defer f.Close() //nolint:errcheck // readonly
+
+	if err := bsp.ImportEntities(f); err != nil {
+		return fmt.Errorf("unable to import entities: %w", err)
+	}
+
+	if err := bsp.WriteToFile(cmd.String("out")); err != nil {
+		return fmt.Errorf("unable to write BSP: %w", err)
+	}
+
+	return nil
+}
+
+// Loads a materials file from the mod filesystem at --moddir if set, from
+// the working directory otherwise.
+func loadMaterials(cmd *cli.Command, path string) (goldsrc.Materials, error) {
+	moddir := cmd.String("moddir")
+	if moddir == "" {
+		return goldsrc.LoadMaterialsFromFile(path)
+	}
+
+	mod, err := modfs.New(moddir)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open mod directory: %w", err)
+	}
+	defer mod.Close() //nolint:errcheck // readonly
+
+	return goldsrc.LoadMaterialsFromFS(mod, path)
+}
+
 func doBSPInfo(ctx context.Context, cmd *cli.Command) error {
-	bsp, err := bsp.LoadFromFile(cmd.Args().Get(0))
+	bsp, err := loadBSP(cmd, cmd.Args().Get(0))
 	if err != nil {
 		return fmt.Errorf("unable to load BSP: %w", err)
 	}
@@ -95,11 +186,16 @@ func doBSPInfo(ctx context.Context, cmd *cli.Command) error {
 }
 
 func doBSPLimits(ctx context.Context, cmd *cli.Command) error {
-	bsp, err := bsp.LoadFromFile(cmd.Args().Get(0))
+	bsp, err := loadBSP(cmd, cmd.Args().Get(0))
 	if err != nil {
 		return fmt.Errorf("unable to load BSP: %w", err)
 	}
 
+	return printBSPLimits(cmd, bsp)
+}
+
+// Prints the limits table, returns an error if a limit is exceeded.
+func printBSPLimits(cmd *cli.Command, bsp *bsp.BSP) error {
 	fmt.Fprintf(
 		cmd.Writer,
 		"%-18s % 9s % 9s % 4s\n",
@@ -143,3 +239,727 @@ func doBSPLimits(ctx context.Context, cmd *cli.Command) error {
 
 	return errors.Join(errs...)
 }
+
+func doBSPValidate(ctx context.Context, cmd *cli.Command) error {
+	bsp, err := loadBSP(cmd, cmd.Args().Get(0))
+	if err != nil {
+		return fmt.Errorf("unable to load BSP: %w", err)
+	}
+
+	errs := bsp.ValidateReferences()
+	for _, err := range errs {
+		fmt.Fprintln(cmd.Writer, err.Error())
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("found %d invalid references", len(errs))
+	}
+
+	return nil
+}
+
+func doBSPDiff(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Args().Len() != 2 {
+		return errors.New("expected two arguments: the original and modified .bsp")
+	}
+
+	a, err := loadBSP(cmd, cmd.Args().Get(0))
+	if err != nil {
+		return fmt.Errorf("unable to load original BSP: %w", err)
+	}
+
+	b, err := loadBSP(cmd, cmd.Args().Get(1))
+	if err != nil {
+		return fmt.Errorf("unable to load modified BSP: %w", err)
+	}
+
+	diff, err := bsp.NewDiff(a, b)
+	if err != nil {
+		return fmt.Errorf("unable to compare BSPs: %w", err)
+	}
+
+	if diff.IsEmpty() {
+		fmt.Fprintln(cmd.Writer, "No differences.")
+		return nil
+	}
+
+	printEntitiesDiff(cmd.Writer, diff.Entities)
+	printTexturesDiff(cmd.Writer, diff.Textures)
+	printLumpsDiff(cmd.Writer, diff.Lumps, diff.Limits)
+
+	return nil
+}
+
+var (
+	diffAdded   = color.New(color.FgGreen).Fprintf
+	diffRemoved = color.New(color.FgRed).Fprintf
+	diffChanged = color.New(color.FgYellow).Fprintf
+)
+
+func printEntitiesDiff(w io.Writer, diff []bsp.EntityDiff) {
+	if len(diff) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w, "Entities:")
+	for _, ent := range diff {
+		switch {
+		case ent.Old == nil:
+			diffAdded(w, "  + %s\n", ent.Name) //nolint:errcheck
+		case ent.New == nil:
+			diffRemoved(w, "  - %s\n", ent.Name) //nolint:errcheck
+		default:
+			diffChanged(w, "  ~ %s\n", ent.Name) //nolint:errcheck
+			for _, kv := range ent.Changes() {
+				fmt.Fprintf(w, "      %s: %q -> %q\n", kv.Key, kv.Old, kv.New)
+			}
+		}
+	}
+	fmt.Fprintln(w)
+}
+
+func printTexturesDiff(w io.Writer, diff bsp.TextureDiff) {
+	if len(diff.Added)+len(diff.Removed)+len(diff.Changed) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w, "Textures:")
+	for _, name := range diff.Added {
+		diffAdded(w, "  + %s\n", name) //nolint:errcheck
+	}
+	for _, name := range diff.Removed {
+		diffRemoved(w, "  - %s\n", name) //nolint:errcheck
+	}
+	for _, name := range diff.Changed {
+		diffChanged(w, "  ~ %s\n", name) //nolint:errcheck
+	}
+	fmt.Fprintln(w)
+}
+
+func printLumpsDiff(w io.Writer, lumps []bsp.LumpDiff, limits []bsp.LimitDiff) {
+	lumps = slices.DeleteFunc(slices.Clone(lumps), func(v bsp.LumpDiff) bool {
+		return !v.Changed
+	})
+	if len(lumps) > 0 {
+		fmt.Fprintf(w, "%-14s % 10s % 10s % 10s % 9s % 9s % 8s\n", "Lump", "Old size", "New size", "Delta", "Old count", "New count", "Delta")
+	}
+
+	for _, v := range lumps {
+		count := fmt.Sprintf("% 9d % 9d % +8d", v.OldCount, v.NewCount, v.NewCount-v.OldCount)
+		if v.NewCount < 0 {
+			count = fmt.Sprintf("% 9s % 9s % 8s", "n/a", "n/a", "n/a")
+		}
+
+		fmt.Fprintf(
+			w, "%-14s % 10d % 10d % +10d %s\n",
+			v.Type.String()[8:], v.OldSize, v.NewSize, v.NewSize-v.OldSize, count,
+		)
+	}
+
+	if len(limits) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "\n%-14s % 9s % 9s % 9s % 5s\n", "Limit", "Old", "New", "Max", "Pct")
+	for _, v := range limits {
+		if v.Max <= 0 {
+			fmt.Fprintf(w, "%-14s % 9d % 9d % 9s\n", v.Desc, v.Old, v.New, "∞")
+			continue
+		}
+
+		fmt.Fprintf(
+			w, "%-14s % 9d % 9d % 9d % 4.0f%%\n",
+			v.Desc, v.Old, v.New, v.Max, math.Ceil(float64(v.New)/float64(v.Max)*100),
+		)
+	}
+}
+
+func doBSPExportMesh(ctx context.Context, cmd *cli.Command) error {
+	path := cmd.Args().Get(0)
+	if path == "" {
+		return errors.New("expected one argument: the .bsp to export")
+	}
+
+	destPath := cmd.String("out")
+	ext := strings.ToLower(filepath.Ext(destPath))
+	if ext != ".obj" && ext != ".gltf" {
+		return fmt.Errorf("unsupported output format '%s', expected .obj or .gltf", ext)
+	}
+
+	b, err := loadBSP(cmd, path)
+	if err != nil {
+		return fmt.Errorf("unable to load BSP: %w", err)
+	}
+
+	wads, err := loadBSPWADs(cmd, b)
+	if err != nil {
+		return err
+	}
+
+	paletteless := b.Variant == bsp.VariantQuake && !cmd.Bool("lightmaps")
+	if paletteless {
+		fmt.Fprintln(cmd.ErrWriter, "Warning: textures of Quake BSPs have no palette, their materials are exported untextured.")
+	}
+
+	scene, err := mesh.New(b, mesh.Options{
+		WADs:         wads,
+		Scale:        float32(cmd.Float("scale")),
+		SkipTextures: cmd.StringSlice("skip-texture"),
+		WorldOnly:    cmd.Bool("world-only"),
+		Lightmaps:    cmd.Bool("lightmaps"),
+	})
+	if err != nil {
+		return fmt.Errorf("unable to build mesh: %w", err)
+	}
+
+	if err := writeMeshTextures(cmd, scene, filepath.Dir(destPath), paletteless); err != nil {
+		return err
+	}
+
+	if ext == ".gltf" {
+		return writeFile(destPath, scene.WriteGLTF)
+	}
+
+	mtlPath := strings.TrimSuffix(destPath, filepath.Ext(destPath)) + ".mtl"
+	if err := writeFile(mtlPath, scene.WriteMTL); err != nil {
+		return err
+	}
+
+	return writeFile(destPath, func(w io.Writer) error {
+		return scene.WriteOBJ(w, filepath.Base(mtlPath))
+	})
+}
+
+// Writes the scene textures as PNG files in a "textures" directory next to
+// the mesh and sets their path in the materials. Textures that could not be
+// rendered are only reported if the BSP has a palette.
+func writeMeshTextures(cmd *cli.Command, scene *mesh.Scene, dir string, paletteless bool) error {
+	if err := os.MkdirAll(filepath.Join(dir, "textures"), 0750); err != nil {
+		return fmt.Errorf("unable to create textures directory: %w", err)
+	}
+
+	for i, mat := range scene.Materials {
+		if mat.Image == nil {
+			if !paletteless {
+				fmt.Fprintf(cmd.ErrWriter, "Texture not found: %s\n", mat.Name)
+			}
+			continue
+		}
+
+		if strings.ContainsAny(mat.Name, `/\`) {
+			return fmt.Errorf("texture name contains a separator: %s", mat.Name)
+		}
+
+		relPath := "textures/" + mat.Name + ".png"
+		if err := writePNG(mat.Image, filepath.Join(dir, relPath)); err != nil {
+			return fmt.Errorf("unable to write texture: %w", err)
+		}
+
+		scene.Materials[i].Path = relPath
+	}
+
+	return nil
+}
+
+// Loads the WADs given with --wad and the ones listed in the BSP worldspawn
+// that can be found in the mod filesystem at --moddir or in one of the
+// --wad-dir directories.
+func loadBSPWADs(cmd *cli.Command, b *bsp.BSP) (wad.Collection, error) {
+	var ret wad.Collection
+	for _, path := range cmd.StringSlice("wad") {
+		wad, err := wad.NewFromFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to open WAD at '%s': %w", path, err)
+		}
+
+		ret = append(ret, wad)
+	}
+
+	var (
+		dirs   = cmd.StringSlice("wad-dir")
+		moddir = cmd.String("moddir")
+	)
+	if len(dirs) == 0 && moddir == "" {
+		return ret, nil
+	}
+
+	names, err := b.WADNames()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read WAD list from BSP: %w", err)
+	}
+
+	var mod *modfs.FS
+	if moddir != "" {
+		if mod, err = modfs.New(moddir); err != nil {
+			return nil, fmt.Errorf("unable to open mod directory: %w", err)
+		}
+		defer mod.Close() //nolint:errcheck // readonly
+	}
+
+names:
+	for _, name := range names {
+		if mod != nil {
+			wad, err := wad.NewFromFS(mod, name)
+			if err == nil {
+				ret = append(ret, wad)
+				continue
+			} else if !errors.Is(err, fs.ErrNotExist) {
+				return nil, fmt.Errorf("unable to open WAD '%s': %w", name, err)
+			}
+		}
+
+		for _, dir := range dirs {
+			path := filepath.Join(dir, name)
+			if _, err := os.Stat(path); err != nil {
+				continue
+			}
+
+			wad, err := wad.NewFromFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("unable to open WAD at '%s': %w", path, err)
+			}
+
+			ret = append(ret, wad)
+			continue names
+		}
+
+		fmt.Fprintf(cmd.ErrWriter, "WAD not found: %s\n", name)
+	}
+
+	return ret, nil
+}
+
+// Creates the file at path and hands it to write.
+func writeFile(path string, write func(io.Writer) error) error {
+	dest, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("unable to open '%s' for writing: %w", path, err)
+	}
+
+	if err := write(dest); err != nil {
+		dest.Close() //nolint:errcheck // in another error path already
+		return err
+	}
+
+	if err := dest.Close(); err != nil {
+		return fmt.Errorf("unable to finalize writing to '%s': %w", path, err)
+	}
+
+	return nil
+}
+
+func doBSPLightmaps(ctx context.Context, cmd *cli.Command) error {
+	dir := cmd.String("dir")
+	if stat, err := os.Stat(dir); err != nil {
+		return fmt.Errorf("unable to use destination directory: %w", err)
+	} else if !stat.IsDir() {
+		return errors.New("output directory paths exists but is not a directory")
+	}
+
+	bsp, err := loadBSP(cmd, cmd.Args().Get(0))
+	if err != nil {
+		return fmt.Errorf("unable to load BSP: %w", err)
+	}
+
+	lightmaps, err := bsp.Lightmaps()
+	if err != nil {
+		return fmt.Errorf("unable to read lightmaps: %w", err)
+	}
+
+	if cmd.Bool("atlas") {
+		return writeLightmapAtlas(lightmaps, dir)
+	}
+
+	for _, lm := range lightmaps {
+		destPath := filepath.Join(dir, fmt.Sprintf("face%d_style%d.png", lm.Face, lm.Style))
+		if err := writePNG(lm.Image, destPath); err != nil {
+			return fmt.Errorf("unable to write lightmap: %w", err)
+		}
+	}
+
+	return nil
+}
+
+type lightmapAtlasIndex struct {
+	Width     int                  `json:"width"`
+	Height    int                  `json:"height"`
+	Lightmaps []lightmapAtlasEntry `json:"lightmaps"`
+}
+
+type lightmapAtlasEntry struct {
+	Face   int   `json:"face"`
+	Style  uint8 `json:"style"`
+	X      int   `json:"x"`
+	Y      int   `json:"y"`
+	Width  int   `json:"width"`
+	Height int   `json:"height"`
+}
+
+// Writes lightmaps.png and its lightmaps.json index to dir.
+func writeLightmapAtlas(lightmaps []bsp.Lightmap, dir string) error {
+	atlas := bsp.NewLightmapAtlas(lightmaps)
+	if err := writePNG(atlas.Image, filepath.Join(dir, "lightmaps.png")); err != nil {
+		return fmt.Errorf("unable to write lightmap atlas: %w", err)
+	}
+
+	index := lightmapAtlasIndex{
+		Width:     atlas.Image.Rect.Dx(),
+		Height:    atlas.Image.Rect.Dy(),
+		Lightmaps: make([]lightmapAtlasEntry, 0, len(atlas.Entries)),
+	}
+	for _, entry := range atlas.Entries {
+		index.Lightmaps = append(index.Lightmaps, lightmapAtlasEntry{
+			Face:   entry.Face,
+			Style:  entry.Style,
+			X:      entry.Rect.Min.X,
+			Y:      entry.Rect.Min.Y,
+			Width:  entry.Rect.Dx(),
+			Height: entry.Rect.Dy(),
+		})
+	}
+
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to marshal lightmap atlas index: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "lightmaps.json"), append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("unable to write lightmap atlas index: %w", err)
+	}
+
+	return nil
+}
+
+func doBSPVis(ctx context.Context, cmd *cli.Command) error {
+	bsp, err := loadBSP(cmd, cmd.Args().Get(0))
+	if err != nil {
+		return fmt.Errorf("unable to load BSP: %w", err)
+	}
+
+	numLeaves := bsp.NumVisLeaves()
+	if numLeaves == 0 {
+		return errors.New("BSP has no visible leaves")
+	}
+	if len(bsp.Visibility) == 0 {
+		fmt.Fprintln(cmd.ErrWriter, "BSP has no visibility data, everything is visible.")
+	}
+
+	type leafVis struct {
+		leaf, visible int
+	}
+
+	var (
+		leaves = make([]leafVis, 0, numLeaves)
+		total  int
+	)
+	for leaf := 1; leaf <= numLeaves; leaf++ {
+		visible, err := bsp.VisibleLeaves(leaf)
+		if err != nil {
+			return fmt.Errorf("unable to decompress PVS: %w", err)
+		}
+
+		leaves = append(leaves, leafVis{leaf, len(visible)})
+		total += len(visible)
+	}
+
+	slices.SortStableFunc(leaves, func(a, b leafVis) int {
+		return b.visible - a.visible
+	})
+
+	average := float64(total) / float64(numLeaves)
+	fmt.Fprintf(cmd.Writer, "Leaves:          %d\n", numLeaves)
+	fmt.Fprintf(cmd.Writer, "Average visible: %.1f (%.2f%%)\n\n", average, average/float64(numLeaves)*100)
+
+	if top := cmd.Int("top"); top > 0 && top < len(leaves) {
+		leaves = leaves[:top]
+	}
+
+	fmt.Fprintf(cmd.Writer, "%-7s %7s %7s  %s\n", "Leaf", "Visible", "Pct", "Center")
+	for _, v := range leaves {
+		fmt.Fprintf(
+			cmd.Writer,
+			"%-7d %7d %6.2f%%  %s\n",
+			v.leaf, v.visible, float64(v.visible)/float64(numLeaves)*100,
+			bsp.Leaves[v.leaf].Center().String(),
+		)
+	}
+
+	return nil
+}
+
+func doBSPWPoly(ctx context.Context, cmd *cli.Command) error {
+	bsp, err := loadBSP(cmd, cmd.Args().Get(0))
+	if err != nil {
+		return fmt.Errorf("unable to load BSP: %w", err)
+	}
+
+	wpoly, err := bsp.WPoly()
+	if err != nil {
+		return fmt.Errorf("unable to estimate wpoly: %w", err)
+	}
+
+	leaves := make([]int, 0, len(wpoly))
+	for leaf := 1; leaf < len(wpoly); leaf++ {
+		leaves = append(leaves, leaf)
+	}
+	slices.SortStableFunc(leaves, func(a, b int) int {
+		return wpoly[b] - wpoly[a]
+	})
+
+	var (
+		budget = cmd.Int("budget")
+		over   int
+	)
+	for _, leaf := range leaves {
+		if budget > 0 && wpoly[leaf] > budget {
+			over++
+		}
+	}
+
+	if top := cmd.Int("top"); top > 0 && top < len(leaves) {
+		leaves = leaves[:top]
+	}
+
+	red := color.New(color.FgRed).Fprintf
+	fmt.Fprintf(cmd.Writer, "%-7s %7s  %s\n", "Leaf", "WPoly", "Center")
+	for _, leaf := range leaves {
+		var printer = fmt.Fprintf
+		if budget > 0 && wpoly[leaf] > budget {
+			printer = red
+		}
+
+		//nolint:errcheck
+		printer(cmd.Writer, "%-7d %7d  %s\n", leaf, wpoly[leaf], bsp.Leaves[leaf].Center().String())
+	}
+
+	if over > 0 {
+		return fmt.Errorf("%d leaves exceed the wpoly budget of %d", over, budget)
+	}
+
+	return nil
+}
+
+func doBSPOptimize(ctx context.Context, cmd *cli.Command) error {
+	path := cmd.Args().Get(0)
+	if path == "" {
+		return errors.New("expected one argument: the .bsp to optimize")
+	}
+
+	bsp, err := loadBSP(cmd, path)
+	if err != nil {
+		return fmt.Errorf("unable to load BSP: %w", err)
+	}
+
+	before, err := statInput(cmd, path)
+	if err != nil {
+		return fmt.Errorf("unable to stat BSP: %w", err)
+	}
+
+	removedTexInfo := bsp.CompactTexInfo()
+	removedTextures := bsp.RemoveUnusedTextures()
+	for _, name := range removedTextures {
+		fmt.Fprintf(cmd.Writer, "Removing unused texture %s\n", name)
+	}
+
+	destPath := cmd.String("out")
+	if err := bsp.WriteToFile(destPath); err != nil {
+		return fmt.Errorf("unable to write BSP: %w", err)
+	}
+
+	after, err := os.Stat(destPath)
+	if err != nil {
+		return fmt.Errorf("unable to stat written BSP: %w", err)
+	}
+
+	fmt.Fprintf(cmd.Writer, "Removed %d textures and %d texinfo.\n", len(removedTextures), removedTexInfo)
+	fmt.Fprintf(cmd.Writer, "Saved %d bytes.\n\n", before.Size()-after.Size())
+
+	return printBSPLimits(cmd, bsp)
+}
+
+func doBSPOverview(ctx context.Context, cmd *cli.Command) error {
+	path := cmd.Args().Get(0)
+	if path == "" {
+		return errors.New("expected one argument: the .bsp to render")
+	}
+
+	dir := cmd.String("dir")
+	if stat, err := os.Stat(dir); err != nil {
+		return fmt.Errorf("unable to use destination directory: %w", err)
+	} else if !stat.IsDir() {
+		return errors.New("output directory paths exists but is not a directory")
+	}
+
+	format := cmd.String("format")
+	if format != "tga" && format != "png" {
+		return fmt.Errorf("unsupported image format '%s', expected tga or png", format)
+	}
+
+	b, err := loadBSP(cmd, path)
+	if err != nil {
+		return fmt.Errorf("unable to load BSP: %w", err)
+	}
+
+	opts := render.OverviewOptions{
+		Width:    cmd.Int("width"),
+		Height:   cmd.Int("height"),
+		Textured: cmd.Bool("textured"),
+		Markers:  cmd.Bool("markers"),
+	}
+	if opts.Textured {
+		if b.Variant == bsp.VariantQuake {
+			fmt.Fprintln(cmd.ErrWriter, "Warning: textures of Quake BSPs have no palette, faces are colored by height.")
+		}
+
+		if opts.WADs, err = loadBSPWADs(cmd, b); err != nil {
+			return err
+		}
+	}
+
+	ov, err := render.NewOverview(b, opts)
+	if err != nil {
+		return fmt.Errorf("unable to render overview: %w", err)
+	}
+
+	var (
+		mapName   = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		imageName = mapName + "." + format
+		imagePath = filepath.Join(dir, imageName)
+	)
+	if format == "png" {
+		err = writePNG(ov.Image, imagePath)
+	} else {
+		err = writeFile(imagePath, func(w io.Writer) error {
+			return render.WriteTGA(w, ov.Image)
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("unable to write overview image: %w", err)
+	}
+
+	return writeFile(filepath.Join(dir, mapName+".txt"), func(w io.Writer) error {
+		return ov.WriteHLTV(w, mapName, "overviews/"+imageName)
+	})
+}
+
+func doBSPPlan(ctx context.Context, cmd *cli.Command) error {
+	bsp, err := loadBSP(cmd, cmd.Args().Get(0))
+	if err != nil {
+		return fmt.Errorf("unable to load BSP: %w", err)
+	}
+
+	var opts render.PlanOptions
+	if cmd.IsSet("min-z") || cmd.IsSet("max-z") {
+		opts.Slice = true
+		opts.MinZ, opts.MaxZ = -math.MaxFloat32, math.MaxFloat32
+		if cmd.IsSet("min-z") {
+			opts.MinZ = float32(cmd.Float("min-z"))
+		}
+		if cmd.IsSet("max-z") {
+			opts.MaxZ = float32(cmd.Float("max-z"))
+		}
+	}
+
+	return writeFile(cmd.String("out"), func(w io.Writer) error {
+		return render.WritePlan(w, bsp, opts)
+	})
+}
+
+func doBSPLightingAdjust(ctx context.Context, cmd *cli.Command) error {
+	adj := bsp.NewLightingAdjustment()
+	adj.Scale = cmd.Float("scale")
+	adj.Gamma = cmd.Float("gamma")
+	if adj.Scale < 0 || adj.Gamma <= 0 {
+		return errors.New("scale must be positive and gamma strictly positive")
+	}
+
+	minValue, maxValue := cmd.Int("min"), cmd.Int("max")
+	if minValue < 0 || maxValue > 0xFF || minValue > maxValue {
+		return errors.New("min and max must be between 0 and 255, min cannot be above max")
+	}
+	adj.Min, adj.Max = uint8(minValue), uint8(maxValue)
+
+	filter := bsp.LightingFilter{
+		Textures: cmd.StringSlice("texture"),
+		Models:   cmd.IntSlice("model"),
+	}
+	for _, style := range cmd.IntSlice("style") {
+		if style < 0 || style >= bsp.NoLightStyle {
+			return fmt.Errorf("invalid light style %d, must be between 0 and %d", style, bsp.NoLightStyle-1)
+		}
+		filter.Styles = append(filter.Styles, uint8(style))
+	}
+
+	bsp, err := loadBSP(cmd, cmd.Args().Get(0))
+	if err != nil {
+		return fmt.Errorf("unable to load BSP: %w", err)
+	}
+
+	n, err := bsp.AdjustLighting(adj, filter)
+	if err != nil {
+		return fmt.Errorf("unable to adjust lighting: %w", err)
+	}
+	fmt.Fprintf(cmd.Writer, "Adjusted %d lightmaps.\n", n)
+
+	if err := bsp.WriteToFile(cmd.String("out")); err != nil {
+		return fmt.Errorf("unable to write BSP: %w", err)
+	}
+
+	return nil
+}
+
+func doBSPResources(ctx context.Context, cmd *cli.Command) error {
+	path := cmd.Args().Get(0)
+	b, err := loadBSP(cmd, path)
+	if err != nil {
+		return fmt.Errorf("unable to load BSP: %w", err)
+	}
+
+	all, err := b.Resources()
+	if err != nil {
+		return fmt.Errorf("unable to list resources: %w", err)
+	}
+
+	valid, invalid := bsp.ValidResources(all)
+	for _, v := range invalid {
+		fmt.Fprintf(cmd.ErrWriter, "invalid path %s\n", v)
+	}
+
+	resources := slices.DeleteFunc(valid, func(res string) bool {
+		return slices.ContainsFunc(cmd.StringSlice("base-dir"), func(dir string) bool {
+			_, err := os.Stat(filepath.Join(dir, res))
+			return err == nil
+		})
+	})
+
+	if !cmd.Bool("write") {
+		for _, v := range resources {
+			fmt.Fprintln(cmd.Writer, v)
+		}
+
+		return invalidResourcesError(invalid)
+	}
+
+	diskPath, err := inputPath(cmd, path)
+	if err != nil {
+		return fmt.Errorf("unable to write resources next to the BSP: %w", err)
+	}
+
+	resPath := strings.TrimSuffix(diskPath, filepath.Ext(diskPath)) + ".res"
+	if err := writeFile(resPath, func(w io.Writer) error {
+		return bsp.WriteRES(w, resources)
+	}); err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.Writer, "Wrote %d resources to '%s'.\n", len(resources), resPath)
+
+	return invalidResourcesError(invalid)
+}
+
+func invalidResourcesError(invalid []string) error {
+	if len(invalid) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("found %d invalid resource paths, they were left out", len(invalid))
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/L-P/goldutil/goldsrc/bsp"
+	"github.com/L-P/goldutil/goldsrc/wad"
+)
+
+func doBSPTexturesExtract(ctx context.Context, cmd *cli.Command) error {
+	dir, out := cmd.String("dir"), cmd.String("out")
+	if (dir == "") == (out == "") {
+		return errors.New("expected either --dir or --out")
+	}
+
+	if dir != "" {
+		stat, err := os.Stat(dir)
+		if err != nil {
+			return fmt.Errorf("unable to use destination directory: %w", err)
+		}
+		if !stat.IsDir() {
+			return errors.New("output directory paths exists but is not a directory")
+		}
+	}
+
+	b, err := loadBSP(cmd, cmd.Args().Get(0))
+	if err != nil {
+		return fmt.Errorf("unable to load BSP: %w", err)
+	}
+
+	if b.Variant == bsp.VariantQuake {
+		return fmt.Errorf("unable to extract textures: %w", bsp.ErrNoTexturePalette)
+	}
+
+	embedded := wad.New()
+	for _, tex := range b.Textures.Textures {
+		if !tex.IsEmbedded() {
+			continue
+		}
+
+		if err := embedded.AddTexture(tex); err != nil {
+			return fmt.Errorf("unable to add texture to WAD: %w", err)
+		}
+	}
+
+	if out != "" {
+		return writeFile(out, embedded.Write)
+	}
+
+	return extractWAD(embedded, dir, !cmd.Bool("no-alpha"))
+}
+
+func doBSPTexturesEmbed(ctx context.Context, cmd *cli.Command) error {
+	path := cmd.Args().Get(0)
+	if path == "" {
+		return errors.New("expected one argument: the .bsp to embed textures into")
+	}
+
+	bsp, err := loadBSP(cmd, path)
+	if err != nil {
+		return fmt.Errorf("unable to load BSP: %w", err)
+	}
+
+	wads, err := loadBSPWADs(cmd, bsp)
+	if err != nil {
+		return err
+	}
+
+	missing := bsp.EmbedTextures(wads)
+	for _, name := range missing {
+		fmt.Fprintf(cmd.ErrWriter, "Texture not found: %s\n", name)
+	}
+
+	if cmd.Bool("remove-wad-key") {
+		if len(missing) > 0 {
+			return fmt.Errorf("%d textures could not be embedded, not removing the wad key", len(missing))
+		}
+
+		if err := bsp.RemoveWADKey(); err != nil {
+			return fmt.Errorf("unable to remove wad key: %w", err)
+		}
+	}
+
+	if err := bsp.WriteToFile(cmd.String("out")); err != nil {
+		return fmt.Errorf("unable to write BSP: %w", err)
+	}
+
+	return nil
+}
+
+func doBSPTexturesReplace(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Args().Len() != 3 {
+		return errors.New("expected three arguments: the .bsp, the name of the texture to replace, and the replacement image")
+	}
+
+	bsp, err := loadBSP(cmd, cmd.Args().Get(0))
+	if err != nil {
+		return fmt.Errorf("unable to load BSP: %w", err)
+	}
+
+	img, err := wad.OpenNamedImage(cmd.Args().Get(2))
+	if err != nil {
+		return fmt.Errorf("unable to open replacement image: %w", err)
+	}
+
+	tex, err := wad.NewMIPTextureFromImage(img)
+	if err != nil {
+		return fmt.Errorf("unable to create texture: %w", err)
+	}
+
+	if err := bsp.ReplaceTexture(cmd.Args().Get(1), tex); err != nil {
+		return fmt.Errorf("unable to replace texture: %w", err)
+	}
+
+	if err := bsp.WriteToFile(cmd.String("out")); err != nil {
+		return fmt.Errorf("unable to write BSP: %w", err)
+	}
+
+	return nil
+}
+
+func doBSPTexturesRename(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Args().Len() != 3 {
+		return errors.New("expected three arguments: the .bsp, the current texture name, and the new name")
+	}
+
+	bsp, err := loadBSP(cmd, cmd.Args().Get(0))
+	if err != nil {
+		return fmt.Errorf("unable to load BSP: %w", err)
+	}
+
+	oldName, newName := cmd.Args().Get(1), cmd.Args().Get(2)
+	if err := bsp.RenameTexture(oldName, newName); err != nil {
+		return fmt.Errorf("unable to rename texture: %w", err)
+	}
+
+	if index, _ := bsp.Textures.Find(newName); !bsp.Textures.Textures[index].IsEmbedded() {
+		fmt.Fprintf(cmd.ErrWriter, "Texture %s is not embedded, it must exist in one of the map WADs.\n", newName)
+	}
+
+	if err := bsp.WriteToFile(cmd.String("out")); err != nil {
+		return fmt.Errorf("unable to write BSP: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/L-P/goldutil/goldsrc/bsp"
+	"github.com/L-P/goldutil/goldsrc/modfs"
+	"github.com/L-P/goldutil/goldsrc/sprite"
+	"github.com/L-P/goldutil/goldsrc/wad"
+)
+
+// Reads a file given as argument from the mod filesystem at --moddir if set,
+// eg. from a PAK, from the working directory otherwise.
+func readInput[T any](
+	cmd *cli.Command,
+	name string,
+	fromFile func(string) (T, error),
+	fromFS func(fs.FS, string) (T, error),
+) (T, error) {
+	moddir := cmd.String("moddir")
+	if moddir == "" {
+		return fromFile(name)
+	}
+
+	mod, err := modfs.New(moddir)
+	if err != nil {
+		var zero T
+		return zero, fmt.Errorf("unable to open mod directory: %w", err)
+	}
+	defer mod.Close() //nolint:errcheck // readonly
+
+	return fromFS(mod, name)
+}
+
+func loadBSP(cmd *cli.Command, name string) (*bsp.BSP, error) {
+	return readInput(cmd, name, bsp.LoadFromFile, bsp.LoadFromFS)
+}
+
+func loadSprite(cmd *cli.Command, name string) (sprite.Sprite, error) {
+	return readInput(cmd, name, sprite.NewFromFile, sprite.NewFromFS)
+}
+
+func loadWAD(cmd *cli.Command, name string) (wad.WAD, error) {
+	return readInput(cmd, name, wad.NewFromFile, wad.NewFromFS)
+}
+
+func statInput(cmd *cli.Command, name string) (fs.FileInfo, error) {
+	return readInput(cmd, name, os.Stat, fs.Stat)
+}
+
+// Returns the path on disk of a file given as argument, for files written
+// next to it. Files read from a PAK have none.
+func inputPath(cmd *cli.Command, name string) (string, error) {
+	moddir := cmd.String("moddir")
+	if moddir == "" {
+		return name, nil
+	}
+
+	mod, err := modfs.New(moddir)
+	if err != nil {
+		return "", fmt.Errorf("unable to open mod directory: %w", err)
+	}
+	defer mod.Close() //nolint:errcheck // readonly
+
+	return mod.Path(name)
+}
@@ -42,17 +42,186 @@ func newApp() *cli.Command {
 			{
 				Name:  "bsp",
 				Usage: "BSP (compiled maps) manipulation.",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "moddir",
+						Usage: "Read the BSPs, WADs, and materials.txt files from this mod directory (eg. 'valve_addon') and its PAKs, falling back to valve like the engine does.",
+					},
+				},
 				Commands: []*cli.Command{
+					{
+						Name:      "diff",
+						Action:    doBSPDiff,
+						ArgsUsage: "<original> <modified>",
+						Usage:     "Show what changed between two compiled maps.",
+						Description: catnl(
+							"Compare two BSPs lump by lump and print the entities that were added, removed, or had their KVs changed, the texture changes, and the size and count deltas of each lump and limit.",
+							"Entities are matched by targetname, or by classname and origin when they have no targetname.",
+						),
+					},
 					{
 						Name:   "entities",
 						Action: doBSPEntities,
 						Usage:  "Print raw entity data from a BSP.",
+						Commands: []*cli.Command{
+							{
+								Name:  "export",
+								Usage: "Write the entities of a BSP to a .ent file.",
+								Description: catnl(
+									"Write the entities of a BSP to a .ent file the way ripent does.",
+									"By default the .ent file is written next to the BSP, using the same base name.",
+								),
+								Flags: []cli.Flag{
+									&cli.StringFlag{
+										Name:  "out",
+										Usage: "Where to write the .ent file instead of next to the BSP.",
+									},
+								},
+								Action: doBSPEntitiesExport,
+							},
+							{
+								Name:  "import",
+								Usage: "Replace the entities of a BSP with the contents of a .ent file.",
+								Description: catnl(
+									"Replace the entities of a BSP with the contents of a .ent file the way ripent does.",
+									"The .ent file is parsed before being imported, entities cannot contain brushes and can only reference brush models (*N) that exist in the BSP.",
+								),
+								Flags: []cli.Flag{
+									&cli.StringFlag{
+										Name:  "ent",
+										Usage: "Path to the .ent file to import, defaults to the .ent file next to the BSP.",
+									},
+									&cli.StringFlag{
+										Name:     "out",
+										Usage:    "Where to write the modified BSP.",
+										Required: true,
+									},
+								},
+								Action: doBSPEntitiesImport,
+							},
+						},
+					},
+					{
+						Name:  "export-mesh",
+						Usage: "Export the geometry of a BSP to OBJ or glTF.",
+						Description: catnl(
+							"Export the world and brush entities of a BSP as a triangle mesh, the output format is chosen using the extension of the --out path: .obj (with a .mtl next to it) or .gltf.",
+							"Textures are written as PNG files in a 'textures' directory next to the mesh. Textures that are not embedded in the BSP are read from the WADs given with --wad, or from the WADs listed in the map that can be found in one of the --wad-dir directories.",
+							"The mesh is Y-up and uses GoldSrc units unless --scale is set. A unit being roughly an inch, a --scale of 0.0254 gives meters.",
+						),
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:     "out",
+								Usage:    "Path to the output .obj or .gltf file.",
+								Required: true,
+							},
+							&cli.StringSliceFlag{
+								Name:  "wad",
+								Usage: "Path to a WAD to read textures from, can be repeated.",
+							},
+							&cli.StringSliceFlag{
+								Name:  "wad-dir",
+								Usage: "Directory where to look for the WADs used by the map (eg. valve), can be repeated.",
+							},
+							&cli.FloatFlag{
+								Name:  "scale",
+								Value: 1,
+								Usage: "Multiplier applied to coordinates.",
+							},
+							&cli.StringSliceFlag{
+								Name:  "skip-texture",
+								Usage: "Don't export faces using this texture (eg. sky, aaatrigger), can be repeated.",
+							},
+							&cli.BoolFlag{
+								Name:  "world-only",
+								Usage: "Don't export brush entities.",
+							},
+							&cli.BoolFlag{
+								Name:  "lightmaps",
+								Usage: "Replace textures with the baked lightmaps to inspect lighting, faces without lightmaps are fullbright.",
+							},
+						},
+						Action: doBSPExportMesh,
 					},
 					{
 						Name:   "info",
 						Action: doBSPInfo,
 						Usage:  "Print parsed data from a BSP.",
 					},
+					{
+						Name:  "lighting",
+						Usage: "Lighting lump manipulation.",
+						Commands: []*cli.Command{
+							{
+								Name:  "adjust",
+								Usage: "Change the brightness of the lightmaps of a BSP without recompiling it.",
+								Description: catnl(
+									"Scale, gamma-correct, then clamp each channel of the lightmaps of a BSP. This is a quick fix for a map that came out slightly too dark or too bright, re-run hlrad for anything else.",
+									"By default all lightmaps are adjusted, use --style, --texture, and --model to only adjust some of them. Filters can be combined and repeated.",
+								),
+								Flags: []cli.Flag{
+									&cli.StringFlag{
+										Name:     "out",
+										Usage:    "Where to write the modified BSP.",
+										Required: true,
+									},
+									&cli.FloatFlag{
+										Name:  "scale",
+										Value: 1,
+										Usage: "Multiplier applied to lightmaps.",
+									},
+									&cli.FloatFlag{
+										Name:  "gamma",
+										Value: 1,
+										Usage: "Gamma correction, values above 1 brighten dark areas.",
+									},
+									&cli.IntFlag{
+										Name:  "min",
+										Value: 0,
+										Usage: "Minimum channel value, between 0 and 255.",
+									},
+									&cli.IntFlag{
+										Name:  "max",
+										Value: 255,
+										Usage: "Maximum channel value, between 0 and 255.",
+									},
+									&cli.IntSliceFlag{
+										Name:  "style",
+										Usage: "Only adjust this light style (0 is the default style), can be repeated.",
+									},
+									&cli.StringSliceFlag{
+										Name:  "texture",
+										Usage: "Only adjust faces using this texture, can be repeated.",
+									},
+									&cli.IntSliceFlag{
+										Name:  "model",
+										Usage: "Only adjust faces of this brush model (0 is the world), can be repeated.",
+									},
+								},
+								Action: doBSPLightingAdjust,
+							},
+						},
+					},
+					{
+						Name:  "lightmaps",
+						Usage: "Extract the lightmaps of a BSP as PNG files.",
+						Description: catnl(
+							"Write the lightmaps of each face of a BSP as PNG files named faceN_styleS.png in the given directory, one per face and light style.",
+							"With --atlas, all lightmaps are packed in a single lightmaps.png and their position is listed in lightmaps.json.",
+						),
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:     "dir",
+								Required: true,
+								Usage:    "Path to the directory where to write PNG files.",
+							},
+							&cli.BoolFlag{
+								Name:  "atlas",
+								Usage: "Pack all lightmaps in a single image with a JSON index.",
+							},
+						},
+						Action: doBSPLightmaps,
+					},
 					{
 						Name:   "limits",
 						Action: doBSPLimits,
@@ -63,6 +232,94 @@ func newApp() *cli.Command {
 							"Exit with status code `1` if the BSP goes over a limit.",
 						),
 					},
+					{
+						Name:  "optimize",
+						Usage: "Remove unused textures and texinfo from a BSP.",
+						Description: catnl(
+							"Remove the textures that no face uses and the unused or duplicate texinfo, then print the new limits. This gives back some headroom on maps that are close to the texinfo limit.",
+							"Frames of used animated (+0name) and random (-0name) textures are always kept.",
+							"Exit with status code `1` if the BSP still goes over a limit.",
+						),
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:     "out",
+								Usage:    "Where to write the optimized BSP.",
+								Required: true,
+							},
+						},
+						Action: doBSPOptimize,
+					},
+					{
+						Name:  "overview",
+						Usage: "Render a top-down overview of a BSP for HLTV.",
+						Description: catnl(
+							"Render an orthographic top-down image of the world, with faces colored by height or textured, and write it along with its HLTV overview description (mapname.txt) in the given directory.",
+							"Copy both files to the 'overviews' directory of the mod. Textures that are not embedded in the BSP are read from the WADs given with --wad, or from the WADs listed in the map that can be found in one of the --wad-dir directories.",
+						),
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:     "dir",
+								Required: true,
+								Usage:    "Path to the directory where to write the image and its description.",
+							},
+							&cli.StringFlag{
+								Name:  "format",
+								Value: "tga",
+								Usage: "Image format, tga to use in game or png.",
+							},
+							&cli.IntFlag{
+								Name:  "width",
+								Value: 1024,
+								Usage: "Image width, HLTV expects 1024x768.",
+							},
+							&cli.IntFlag{
+								Name:  "height",
+								Value: 768,
+								Usage: "Image height, HLTV expects 1024x768.",
+							},
+							&cli.BoolFlag{
+								Name:  "textured",
+								Usage: "Use textures instead of colors by height.",
+							},
+							&cli.StringSliceFlag{
+								Name:  "wad",
+								Usage: "Path to a WAD to read textures from, can be repeated.",
+							},
+							&cli.StringSliceFlag{
+								Name:  "wad-dir",
+								Usage: "Directory where to look for the WADs used by the map (eg. valve), can be repeated.",
+							},
+							&cli.BoolFlag{
+								Name:  "markers",
+								Usage: "Draw spawn points and point entities.",
+							},
+						},
+						Action: doBSPOverview,
+					},
+					{
+						Name:  "plan",
+						Usage: "Draw an SVG plan of a BSP with its entities.",
+						Description: catnl(
+							"Draw a top-down SVG plan of the world edges, trigger brush models, and point entities. Entities are drawn as icons labeled with their classname, hovering entities and triggers in a browser shows their KVs.",
+							"Use --min-z and --max-z to only draw a single floor.",
+						),
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:     "out",
+								Usage:    "Path to the output .svg file.",
+								Required: true,
+							},
+							&cli.FloatFlag{
+								Name:  "min-z",
+								Usage: "Don't draw anything below this height.",
+							},
+							&cli.FloatFlag{
+								Name:  "max-z",
+								Usage: "Don't draw anything above this height.",
+							},
+						},
+						Action: doBSPPlan,
+					},
 					{
 						Name: "remap-materials",
 						Description: catnl(
@@ -96,6 +353,167 @@ func newApp() *cli.Command {
 						},
 						Action: doBSPRemapMaterials,
 					},
+					{
+						Name:  "resources",
+						Usage: "List the files a BSP needs at runtime.",
+						Description: catnl(
+							"List the models, sprites, sounds, sky, and WADs referenced by the entities of a BSP, relative to the mod directory.",
+							"With --write, the list is written to a .res file next to the BSP for the engine to send the files to clients that don't have them.",
+							"Maps using sentences or env_message also depend on sound/sentences.txt and titles.txt.",
+						),
+						Flags: []cli.Flag{
+							&cli.BoolFlag{
+								Name:  "write",
+								Usage: "Write the resources to a .res file next to the BSP instead of printing them.",
+							},
+							&cli.StringSliceFlag{
+								Name:  "base-dir",
+								Usage: "Drop resources that exist in this directory (eg. valve), can be repeated.",
+							},
+						},
+						Action: doBSPResources,
+					},
+					{
+						Name:  "textures",
+						Usage: "Textures manipulation.",
+						Commands: []*cli.Command{
+							{
+								Name:  "embed",
+								Usage: "Embed the textures a BSP reads from WADs.",
+								Description: catnl(
+									"Copy the textures a BSP reads from WADs inside the BSP itself, like compiling with -wadinclude would.",
+									"Textures are read from the WADs given with --wad, or from the WADs listed in the map that can be found in one of the --wad-dir directories.",
+								),
+								Flags: []cli.Flag{
+									&cli.StringFlag{
+										Name:     "out",
+										Usage:    "Where to write the modified BSP.",
+										Required: true,
+									},
+									&cli.StringSliceFlag{
+										Name:  "wad",
+										Usage: "Path to a WAD to read textures from, can be repeated.",
+									},
+									&cli.StringSliceFlag{
+										Name:  "wad-dir",
+										Usage: "Directory where to look for the WADs used by the map (eg. valve), can be repeated.",
+									},
+									&cli.BoolFlag{
+										Name:  "remove-wad-key",
+										Usage: "Remove the worldspawn wad key so the map does not require any WAD, fails if a texture could not be embedded.",
+									},
+								},
+								Action: doBSPTexturesEmbed,
+							},
+							{
+								Name:  "extract",
+								Usage: "Extract the textures embedded in a BSP as PNG files or a WAD.",
+								Description: catnl(
+									"Extract the textures embedded in a BSP as a bunch of PNG files in the given --dir, or as a single WAD with --out.",
+									"Textures that are not embedded are only referenced by name in the BSP and cannot be extracted.",
+								),
+								Flags: []cli.Flag{
+									&cli.StringFlag{
+										Name:  "dir",
+										Usage: "Path to the directory where to write PNG files.",
+									},
+									&cli.StringFlag{
+										Name:  "out",
+										Usage: "Path to the output .wad file.",
+									},
+									&cli.BoolFlag{
+										Name: "no-alpha",
+										Usage: catnl(
+											"Don't add an alpha channel and keep the original textures palette verbatim.",
+											"By default goldutil rewrites the palette to add an alpha channel on transparent texture (those that start with a '{').",
+										),
+									},
+								},
+								Action: doBSPTexturesExtract,
+							},
+							{
+								Name:      "rename",
+								Usage:     "Rename a texture of a BSP.",
+								ArgsUsage: "<bsp> <old> <new>",
+								Description: catnl(
+									"Rename a texture of a BSP. Names are limited to 15 characters, textures that are not embedded will be searched in the map WADs using their new name.",
+									"Sky, liquid, and trigger textures cannot be renamed to regular textures and vice versa as the engine treats them differently based on their name.",
+								),
+								Flags: []cli.Flag{
+									&cli.StringFlag{
+										Name:     "out",
+										Usage:    "Where to write the modified BSP.",
+										Required: true,
+									},
+								},
+								Action: doBSPTexturesRename,
+							},
+							{
+								Name:      "replace",
+								Usage:     "Replace a texture of a BSP with a PNG image.",
+								ArgsUsage: "<bsp> <name> <image>",
+								Description: catnl(
+									"Replace a texture of a BSP with a paletted PNG image, the texture keeps its name and is embedded in the BSP.",
+									"If the image dimensions differ from the original texture, texture coordinates are scaled so the texture covers the same area and the lightmaps of affected faces are resampled to match.",
+								),
+								Flags: []cli.Flag{
+									&cli.StringFlag{
+										Name:     "out",
+										Usage:    "Where to write the modified BSP.",
+										Required: true,
+									},
+								},
+								Action: doBSPTexturesReplace,
+							},
+						},
+					},
+					{
+						Name:   "validate",
+						Action: doBSPValidate,
+						Usage:  "Check the indexes between the lumps of a BSP.",
+						Description: catnl(
+							"Check that every index from a lump to another points to an existing entry: face to surfedge, surfedge to edge, edge to vertex, node and leaf children, marksurface to face, model headnodes, and texinfo to miptex.",
+							"Each invalid index is printed along with the lump name and entry index.",
+							"Exit with status code `1` if the BSP has any invalid index.",
+						),
+					},
+					{
+						Name:  "vis",
+						Usage: "Report how many leaves are visible from each leaf.",
+						Description: catnl(
+							"Decompress the PVS (potentially visible set) of each leaf and report the average fraction of the map visible from a leaf, and the leaves that see the most.",
+							"Leaf centers are printed as .map coordinates to find them in the editor.",
+						),
+						Flags: []cli.Flag{
+							&cli.IntFlag{
+								Name:  "top",
+								Value: 10,
+								Usage: "Number of leaves to list, 0 lists all leaves.",
+							},
+						},
+						Action: doBSPVis,
+					},
+					{
+						Name:  "wpoly",
+						Usage: "Estimate the worst-case world polygon count seen from each leaf.",
+						Description: catnl(
+							"Estimate the worst-case world polygon count (r_speeds wpoly) seen from each leaf by counting the faces of all leaves in its PVS. Frustum and backface culling are not accounted for so in-game values will be lower.",
+							"Leaf centers are printed as .map coordinates to find them in the editor.",
+							"Exit with status code `1` if a leaf goes over the --budget.",
+						),
+						Flags: []cli.Flag{
+							&cli.IntFlag{
+								Name:  "top",
+								Value: 10,
+								Usage: "Number of leaves to list, 0 lists all leaves.",
+							},
+							&cli.IntFlag{
+								Name:  "budget",
+								Usage: "Maximum wpoly allowed in a leaf.",
+							},
+						},
+						Action: doBSPWPoly,
+					},
 				},
 			},
 
@@ -175,7 +593,7 @@ func newApp() *cli.Command {
 						Usage:  "Create a graphviz digraph of entity caller/callee relationships.",
 						Description: catnl(
 							"Create a graphviz digraph of entity caller/callee relationships from a .map file.",
-							"ripent exports use the same format and can be read too. Output is written to STDOUT.",
+							"ripent exports use the same format and can be read too, as well as the entities of compiled .bsp files. Output is written to STDOUT.",
 						),
 					},
 
@@ -187,7 +605,7 @@ func newApp() *cli.Command {
 							&cli.StringFlag{
 								Name:  "moddir",
 								Value: ".",
-								Usage: "root of the mod directory (eg. 'valve'), defaults to the current working directory.",
+								Usage: "root of the mod directory (eg. 'valve'), defaults to the current working directory. titles.txt falls back to valve like in the engine.",
 							},
 						},
 					},
@@ -198,6 +616,23 @@ func newApp() *cli.Command {
 				Name:  "mod",
 				Usage: "Misc modding utilities",
 				Commands: []*cli.Command{
+					{
+						Name:  "check-resources",
+						Usage: "Report files and textures missing for the BSPs of a mod.",
+						Description: catnl(
+							"Loads all BSP files in the maps directory of the mod and looks for the files they reference the way the engine does: in the _addon, _hd, base, and _downloads directories of the mod, then in valve.",
+							"Textures that are neither embedded nor in the WADs used by the map are reported too.",
+							"Exits with status code 1 if anything is missing.",
+						),
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "moddir",
+								Value: "valve_addon",
+								Usage: "Path of the mod directory containing the maps directory.",
+							},
+						},
+						Action: doModCheckResources,
+					},
 					{
 						Name:  "filter-materials",
 						Usage: "Filter unused materials out of materials.txt.",
@@ -230,6 +665,10 @@ func newApp() *cli.Command {
 								Value: "valve_addon/maps",
 								Usage: "Path of the directory containing the BSPs to use as a used texture list.",
 							},
+							&cli.StringFlag{
+								Name:  "moddir",
+								Usage: "Look for the input WADs in this mod directory (eg. 'valve_addon'), falling back to valve like the engine does.",
+							},
 							&cli.StringFlag{
 								Name:  "out",
 								Value: "valve_addon/filtered.wad",
@@ -238,12 +677,112 @@ func newApp() *cli.Command {
 						},
 						Action: doModFilterWADs,
 					},
+					{
+						Name:  "liblist",
+						Usage: "Validate and edit the liblist.gam of a mod.",
+						Description: catnl(
+							"Check the values of the keys read by the engine in the liblist.gam of a mod, and that the maps (startmap, trainmap) and game DLLs (gamedll, gamedll_linux, gamedll_osx) it references exist.",
+							"Comments and unknown keys are kept as is when writing the file back.",
+							"With --set, keys are changed or added and the file is written back if it is valid, liblist.gam is created if it does not exist.",
+							"Exits with status code 1 if liblist.gam is invalid.",
+						),
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "moddir",
+								Value: ".",
+								Usage: "Path of the mod directory containing liblist.gam, defaults to the current working directory.",
+							},
+							&cli.StringSliceFlag{
+								Name:  "set",
+								Usage: "Set a key, as key=value, can be repeated.",
+							},
+						},
+						Action: doModLiblist,
+					},
+					{
+						Name:      "pack",
+						Usage:     "Collect the BSPs and every file they need in a directory or ZIP ready for release.",
+						ArgsUsage: "<bsp0> [<bspx>…]",
+						Description: catnl(
+							"Copy the given BSPs and the models, sprites, sounds, skies, titles, and sentences they use from the mod directory to a new directory, or to a ZIP if the output ends with .zip.",
+							"Files are looked up the way the engine does (see check-resources), files that are identical in the base game are left out.",
+							`Textures that are not embedded and not from a base game WAD are copied to a single WAD, the "wad" key of the packed BSPs is rewritten to use it.`,
+							"If the mod has its own sound/materials.txt, only the entries for textures used by the BSPs are kept.",
+							"A .res file is written for each BSP. Nothing is written if anything is missing.",
+						),
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "moddir",
+								Value: "valve_addon",
+								Usage: "Path of the mod directory to read files from.",
+							},
+							&cli.StringFlag{
+								Name:     "out",
+								Required: true,
+								Usage:    "Path to the output directory or .zip file, it must not exist.",
+							},
+							&cli.StringFlag{
+								Name:  "wad-name",
+								Value: "pack.wad",
+								Usage: "Name of the WAD holding the textures of the packed BSPs.",
+							},
+						},
+						Action: doModPack,
+					},
+				},
+			},
+
+			{
+				Name:  "pak",
+				Usage: "PAK archives manipulation.",
+				Commands: []*cli.Command{
+					{
+						Name:  "create",
+						Usage: "Create a PAK archive from the files of a directory.",
+						Description: catnl(
+							"Create a PAK archive containing all the files of the given directory, recursively.",
+							"Paths are stored relative to the directory and cannot exceed 55 chars.",
+						),
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:     "out",
+								Required: true,
+								Usage:    "Path to the output .pak file.",
+							},
+						},
+						Action: doPAKCreate,
+					},
+
+					{
+						Name:  "extract",
+						Usage: "Extract the files of a PAK archive in the given DIR.",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:     "dir",
+								Required: true,
+								Usage:    "Path to the directory where to write files.",
+							},
+						},
+						Action: doPAKExtract,
+					},
+
+					{
+						Name:   "list",
+						Action: doPAKList,
+						Usage:  "List the files of a PAK archive and their size.",
+					},
 				},
 			},
 
 			{
 				Name:  "spr",
 				Usage: "Sprite manipulation.",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "moddir",
+						Usage: "Read the sprites to extract or inspect from this mod directory (eg. 'valve_addon') and its PAKs, falling back to valve like the engine does.",
+					},
+				},
 				Commands: []*cli.Command{
 					{
 						Name:  "create",
@@ -320,6 +859,12 @@ func newApp() *cli.Command {
 			{
 				Name:  "wad",
 				Usage: "Texture files manipulation.",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "moddir",
+						Usage: "Read the WADs to extract or inspect from this mod directory (eg. 'valve_addon') and its PAKs, falling back to valve like the engine does.",
+					},
+				},
 				Commands: []*cli.Command{
 					{
 						Name:  "create",
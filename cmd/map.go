@@ -5,9 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/urfave/cli/v3"
 
+	"github.com/L-P/goldutil/goldsrc/bsp"
+	"github.com/L-P/goldutil/goldsrc/modfs"
 	"github.com/L-P/goldutil/goldsrc/qmap"
 	"github.com/L-P/goldutil/neat"
 )
@@ -15,10 +19,15 @@ import (
 func doMapGraph(ctx context.Context, cmd *cli.Command) error {
 	path := cmd.Args().Get(0)
 	if path == "" {
-		return errors.New("expected one argument: the .map to parse and graph")
+		return errors.New("expected one argument: the .map or .bsp to parse and graph")
 	}
 
-	qm, err := loadQMap(cmd.Args().Get(0))
+	load := loadQMap
+	if strings.EqualFold(filepath.Ext(path), ".bsp") {
+		load = loadBSPQMap
+	}
+
+	qm, err := load(path)
 	if err != nil {
 		return fmt.Errorf("unable to read from map: %w", err)
 	}
@@ -34,10 +43,11 @@ func doNeat(ctx context.Context, cmd *cli.Command) error {
 		return fmt.Errorf("unable to read from map: %w", err)
 	}
 
-	mod, err := os.OpenRoot(cmd.String("moddir"))
+	mod, err := modfs.New(cmd.String("moddir"))
 	if err != nil {
-		return fmt.Errorf("unable to open current working directory: %w", err)
+		return fmt.Errorf("unable to open mod directory: %w", err)
 	}
+	defer mod.Close() //nolint:errcheck // readonly
 
 	if err := neat.Neatify(qm, mod); err != nil {
 		return fmt.Errorf("unable to neatify map: %w", err)
@@ -71,3 +81,13 @@ func loadQMap(path string) (*qmap.QMap, error) {
 
 	return qmap.LoadFromFile(path)
 }
+
+// Compiled maps carry their entities in a lump, read them from there.
+func loadBSPQMap(path string) (*qmap.QMap, error) {
+	b, err := bsp.LoadFromFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load BSP: %w", err)
+	}
+
+	return b.LoadEntities()
+}
@@ -2,7 +2,9 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
@@ -11,6 +13,7 @@ import (
 
 	"github.com/L-P/goldutil/goldsrc"
 	"github.com/L-P/goldutil/goldsrc/bsp"
+	"github.com/L-P/goldutil/goldsrc/modfs"
 	"github.com/L-P/goldutil/goldsrc/wad"
 	"github.com/L-P/goldutil/internal/set"
 )
@@ -54,10 +57,18 @@ func doModFilterWADs(ctx context.Context, cmd *cli.Command) error {
 		return fmt.Errorf("unable to obtain absolute output path: %w", err)
 	}
 
+	var mod *modfs.FS
+	if moddir := cmd.String("moddir"); moddir != "" {
+		if mod, err = modfs.New(moddir); err != nil {
+			return fmt.Errorf("unable to open mod directory: %w", err)
+		}
+		defer mod.Close() //nolint:errcheck // readonly
+	}
+
 	for i, rawPath := range cmd.Args().Slice() {
-		path, err := filepath.Abs(rawPath)
+		path, err := filterWADPath(mod, rawPath)
 		if err != nil {
-			return fmt.Errorf("unable to obtain absolute path for WAD at '%s' : %w", rawPath, err)
+			return err
 		}
 
 		if path == destPath {
@@ -66,12 +77,17 @@ func doModFilterWADs(ctx context.Context, cmd *cli.Command) error {
 		}
 
 		fmt.Fprintf(cmd.Writer, "Parsing WAD %d/%d at '%s'\n", i+1, cmd.Args().Len(), path)
-		wad, err := wad.NewFromFile(path)
+		var input wad.WAD
+		if mod != nil {
+			input, err = wad.NewFromFS(mod, rawPath)
+		} else {
+			input, err = wad.NewFromFile(path)
+		}
 		if err != nil {
 			return fmt.Errorf("unable to open WAD at '%s': %w", path, err)
 		}
 
-		for _, name := range wad.Names() {
+		for _, name := range input.Names() {
 			if !seen.Has(name) {
 				continue
 			}
@@ -81,7 +97,7 @@ func doModFilterWADs(ctx context.Context, cmd *cli.Command) error {
 			}
 
 			stored.Set(name)
-			tex, ok := wad.GetTexture(name)
+			tex, ok := input.GetTexture(name)
 			if !ok {
 				return fmt.Errorf("unable to read texture '%s' from WAD at '%s'", name, path)
 			}
@@ -110,6 +126,28 @@ func doModFilterWADs(ctx context.Context, cmd *cli.Command) error {
 	return nil
 }
 
+// Returns the absolute path of an input WAD of filter-wads for display and
+// to skip the output WAD. WADs read from a PAK keep their mod-relative name.
+func filterWADPath(mod *modfs.FS, name string) (string, error) {
+	path := name
+	if mod != nil {
+		var err error
+		path, err = mod.Path(name)
+		if errors.Is(err, modfs.ErrInPAK) {
+			return name, nil
+		} else if err != nil {
+			return "", fmt.Errorf("unable to find WAD in mod directory: %w", err)
+		}
+	}
+
+	ret, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("unable to obtain absolute path for WAD at '%s' : %w", path, err)
+	}
+
+	return ret, nil
+}
+
 func getUsedTextureNames(paths []string) (set.PresenceSet[string], error) {
 	seen := set.NewPresenceSet[string](0)
 	for _, path := range paths {
@@ -125,3 +163,186 @@ func getUsedTextureNames(paths []string) (set.PresenceSet[string], error) {
 
 	return seen, nil
 }
+
+func doModCheckResources(ctx context.Context, cmd *cli.Command) error {
+	moddir := cmd.String("moddir")
+	bspPaths, err := filepath.Glob(filepath.Join(moddir, "maps", "*.bsp"))
+	if err != nil {
+		return fmt.Errorf("unable to glob for BSP files: %w", err)
+	}
+
+	mod, err := modfs.New(moddir)
+	if err != nil {
+		return fmt.Errorf("unable to open mod directory: %w", err)
+	}
+	defer mod.Close() //nolint:errcheck // readonly
+
+	var (
+		wads    = map[string]wad.WAD{} // by name
+		missing int
+	)
+	for _, path := range bspPaths {
+		b, err := bsp.LoadFromFile(path)
+		if err != nil {
+			return fmt.Errorf("unable to load BSP at '%s': %w", path, err)
+		}
+
+		resources, err := b.Resources()
+		if err != nil {
+			return fmt.Errorf("unable to list resources of '%s': %w", path, err)
+		}
+
+		var collection wad.Collection
+		for _, res := range resources {
+			if !fs.ValidPath(res) {
+				fmt.Fprintf(cmd.Writer, "%s: invalid path %s\n", path, res)
+				missing++
+				continue
+			}
+
+			_, err := fs.Stat(mod, res)
+			if errors.Is(err, fs.ErrNotExist) {
+				fmt.Fprintf(cmd.Writer, "%s: missing file %s\n", path, res)
+				missing++
+				continue
+			} else if err != nil {
+				return fmt.Errorf("unable to look for '%s': %w", res, err)
+			}
+
+			if !strings.EqualFold(filepath.Ext(res), ".wad") {
+				continue
+			}
+
+			if _, ok := wads[res]; !ok {
+				wad, err := wad.NewFromFS(mod, res)
+				if err != nil {
+					return fmt.Errorf("unable to open WAD '%s': %w", res, err)
+				}
+				wads[res] = wad
+			}
+			collection = append(collection, wads[res])
+		}
+
+		for _, tex := range b.Textures.Textures {
+			if tex.IsEmbedded() {
+				continue
+			}
+
+			if _, ok := collection.GetTexture(tex.Name.String()); !ok {
+				fmt.Fprintf(cmd.Writer, "%s: missing texture %s\n", path, tex.Name.String())
+				missing++
+			}
+		}
+	}
+
+	if missing > 0 {
+		return fmt.Errorf("found %d missing resources", missing)
+	}
+
+	fmt.Fprintf(cmd.Writer, "Checked %d BSPs, no missing resources.\n", len(bspPaths))
+
+	return nil
+}
+
+func doModLiblist(ctx context.Context, cmd *cli.Command) error {
+	moddir := cmd.String("moddir")
+	path := filepath.Join(moddir, "liblist.gam")
+
+	liblist, err := goldsrc.NewLiblistFromFile(path)
+	if errors.Is(err, fs.ErrNotExist) && cmd.IsSet("set") {
+		liblist = &goldsrc.Liblist{}
+	} else if err != nil {
+		return fmt.Errorf("unable to load liblist.gam: %w", err)
+	}
+
+	for _, v := range cmd.StringSlice("set") {
+		key, value, ok := strings.Cut(v, "=")
+		if !ok || key == "" || strings.ContainsAny(key, " \t\"") || strings.Contains(value, `"`) {
+			return fmt.Errorf("invalid --set '%s', expected key=value", v)
+		}
+
+		liblist.Set(key, value)
+	}
+
+	errs, err := validateLiblistFiles(moddir, liblist)
+	if err != nil {
+		return err
+	}
+	errs = append(liblist.Validate(), errs...)
+
+	if len(errs) > 0 {
+		for _, err := range errs {
+			fmt.Fprintln(cmd.Writer, err.Error())
+		}
+
+		return fmt.Errorf("found %d issues in liblist.gam", len(errs))
+	}
+
+	if !cmd.IsSet("set") {
+		fmt.Fprintln(cmd.Writer, "liblist.gam is valid.")
+		return nil
+	}
+
+	if err := writeFile(path, liblist.Write); err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.Writer, "Wrote '%s'.\n", path)
+
+	return nil
+}
+
+// Checks that the maps and game DLLs referenced by the liblist exist. Maps
+// are looked up in the mod filesystem and fallback_dir, DLLs are only loaded
+// from the mod directory itself.
+func validateLiblistFiles(moddir string, liblist *goldsrc.Liblist) ([]error, error) {
+	mod, err := modfs.New(moddir)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open mod directory: %w", err)
+	}
+	defer mod.Close() //nolint:errcheck // readonly
+
+	var (
+		errs     []error
+		root     = filepath.Dir(filepath.Clean(moddir))
+		fallback = ""
+	)
+	if dir, ok := liblist.Get("fallback_dir"); ok && dir != "" {
+		fallback = filepath.Join(root, dir)
+		if stat, err := os.Stat(fallback); err != nil || !stat.IsDir() {
+			errs = append(errs, fmt.Errorf("fallback_dir: directory '%s' not found", fallback))
+		}
+	}
+
+	for _, key := range []string{"startmap", "trainmap"} {
+		name, ok := liblist.Get(key)
+		if !ok || name == "" {
+			continue
+		}
+
+		path := "maps/" + name + ".bsp"
+		if _, err := fs.Stat(mod, path); err == nil {
+			continue
+		}
+		if fallback != "" {
+			if _, err := os.Stat(filepath.Join(fallback, filepath.FromSlash(path))); err == nil {
+				continue
+			}
+		}
+
+		errs = append(errs, fmt.Errorf("%s: %s not found", key, path))
+	}
+
+	for _, key := range []string{"gamedll", "gamedll_linux", "gamedll_osx"} {
+		dll, ok := liblist.Get(key)
+		if !ok || dll == "" {
+			continue
+		}
+
+		path := filepath.Join(moddir, filepath.FromSlash(strings.ReplaceAll(dll, `\`, "/")))
+		if _, err := os.Stat(path); err != nil {
+			errs = append(errs, fmt.Errorf("%s: '%s' not found", key, path))
+		}
+	}
+
+	return errs, nil
+}
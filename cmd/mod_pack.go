@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/L-P/goldutil/goldsrc/modfs"
+	"github.com/L-P/goldutil/goldsrc/modpack"
+)
+
+func doModPack(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Args().Len() == 0 {
+		return errors.New("expected at least one BSP to pack")
+	}
+
+	out := cmd.String("out")
+	if _, err := os.Stat(out); err == nil {
+		return fmt.Errorf("'%s' already exists", out)
+	}
+
+	mod, err := modfs.New(cmd.String("moddir"))
+	if err != nil {
+		return fmt.Errorf("unable to open mod directory: %w", err)
+	}
+	defer mod.Close() //nolint:errcheck // readonly
+
+	base, err := modfs.NewBase(cmd.String("moddir"))
+	if err != nil {
+		return fmt.Errorf("unable to open base game directory: %w", err)
+	}
+	defer base.Close() //nolint:errcheck // readonly
+
+	p := modpack.New(mod, base, cmd.String("wad-name"))
+	for _, path := range cmd.Args().Slice() {
+		if err := p.AddBSP(path); err != nil {
+			return err
+		}
+	}
+
+	for _, v := range p.Missing() {
+		fmt.Fprintln(cmd.Writer, v)
+	}
+
+	n, err := p.Write(out)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmd.Writer, "Packed %d files to '%s'.\n", n, out)
+
+	return nil
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/L-P/goldutil/goldsrc/pak"
+)
+
+func doPAKList(ctx context.Context, cmd *cli.Command) error {
+	archive, err := pak.NewFromFile(cmd.Args().Get(0))
+	if err != nil {
+		return fmt.Errorf("unable to open PAK: %w", err)
+	}
+	defer archive.Close() //nolint:errcheck // readonly
+
+	for _, entry := range archive.Entries() {
+		fmt.Fprintf(cmd.Writer, "%10d %s\n", entry.Size, entry.NameString())
+	}
+
+	return nil
+}
+
+func doPAKExtract(ctx context.Context, cmd *cli.Command) error {
+	dir := cmd.String("dir")
+	if stat, err := os.Stat(dir); err != nil {
+		return fmt.Errorf("unable to use destination directory: %w", err)
+	} else if !stat.IsDir() {
+		return errors.New("output directory paths exists but is not a directory")
+	}
+
+	archive, err := pak.NewFromFile(cmd.Args().Get(0))
+	if err != nil {
+		return fmt.Errorf("unable to open PAK: %w", err)
+	}
+	defer archive.Close() //nolint:errcheck // readonly
+
+	for _, entry := range archive.Entries() {
+		name := entry.NameString()
+		local := filepath.FromSlash(name)
+		if !filepath.IsLocal(local) {
+			return fmt.Errorf("'%s' would be extracted outside of the destination directory", name)
+		}
+
+		dest := filepath.Join(dir, local)
+		if err := os.MkdirAll(filepath.Dir(dest), 0750); err != nil {
+			return fmt.Errorf("unable to create directory for '%s': %w", name, err)
+		}
+
+		if err := writeFile(dest, func(w io.Writer) error {
+			f, err := archive.Open(name)
+			if err != nil {
+				return err
+			}
+			defer f.Close() //nolint:errcheck // readonly
+
+			if _, err := io.Copy(w, f); err != nil {
+				return fmt.Errorf("unable to extract '%s': %w", name, err)
+			}
+
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(cmd.Writer, "Extracted %d files to '%s'.\n", len(archive.Entries()), dir)
+
+	return nil
+}
+
+func doPAKCreate(ctx context.Context, cmd *cli.Command) error {
+	dir := cmd.Args().Get(0)
+	if dir == "" {
+		return errors.New("expected one argument: the directory to archive")
+	}
+
+	var (
+		fsys  = os.DirFS(dir)
+		names []string
+	)
+	if err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.Type().IsRegular() {
+			names = append(names, path)
+		}
+
+		return nil
+	}); err != nil {
+		return fmt.Errorf("unable to list files to archive: %w", err)
+	}
+
+	out := cmd.String("out")
+	if err := writeFile(out, func(w io.Writer) error {
+		return pak.Write(w, fsys, names)
+	}); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmd.Writer, "Wrote %d files to '%s'.\n", len(names), out)
+
+	return nil
+}
@@ -22,7 +22,7 @@ func doSpriteExtract(ctx context.Context, cmd *cli.Command) error {
 		return errors.New("expected one argument: the .spr to parse and extract")
 	}
 
-	spr, err := sprite.NewFromFile(path)
+	spr, err := loadSprite(cmd, path)
 	if err != nil {
 		return fmt.Errorf("unable to open sprite: %w", err)
 	}
@@ -82,7 +82,7 @@ func doSpriteInfo(ctx context.Context, cmd *cli.Command) error {
 		return errors.New("expected one argument: the .spr to parse and display")
 	}
 
-	spr, err := sprite.NewFromFile(path)
+	spr, err := loadSprite(cmd, path)
 	if err != nil {
 		return fmt.Errorf("unable to open sprite: %w", err)
 	}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"image"
 	"image/png"
 	"os"
 	"path/filepath"
@@ -17,7 +18,7 @@ import (
 )
 
 func doWADInfo(ctx context.Context, cmd *cli.Command) error {
-	wad3, err := wad.NewFromFile(cmd.Args().Get(0))
+	wad3, err := loadWAD(cmd, cmd.Args().Get(0))
 	if err != nil {
 		return fmt.Errorf("unable to open and parse WAD file: %w", err)
 	}
@@ -46,7 +47,7 @@ func doWADExtract(ctx context.Context, cmd *cli.Command) error {
 		return errors.New("output directory paths exists but is not a directory")
 	}
 
-	wad3, err := wad.NewFromFile(cmd.Args().Get(0))
+	wad3, err := loadWAD(cmd, cmd.Args().Get(0))
 	if err != nil {
 		return fmt.Errorf("unable to open and parse WAD file: %w", err)
 	}
@@ -80,6 +81,10 @@ func writeTexture(tex wad.MIPTexture, destPath string, withAlpha bool) error {
 		return fmt.Errorf("unable to render texture: %w", err)
 	}
 
+	return writePNG(img, destPath)
+}
+
+func writePNG(img image.Image, destPath string) error {
 	dest, err := os.OpenFile(destPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
 	if err != nil {
 		return fmt.Errorf("unable to open '%s' for writing: %w", destPath, err)
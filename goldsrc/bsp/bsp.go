@@ -2,35 +2,70 @@
 package bsp
 
 import (
+	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"sort"
 	"strings"
 )
 
-const BSPVersionGoldSrc = 30
+const (
+	BSPVersionQuake   = 29
+	BSPVersionGoldSrc = 30
+)
+
+// Layout of a BSP file, lumps are always in LumpType order once loaded.
+type Variant int
+
+const (
+	VariantGoldSrc Variant = iota
+	// Blue Shift swaps the Entities and Planes entries of the lump index.
+	VariantBlueShift
+	// Quake textures have no palette and its lighting is grayscale.
+	VariantQuake
+)
+
+func (v Variant) String() string {
+	switch v {
+	case VariantGoldSrc:
+		return "GoldSrc"
+	case VariantBlueShift:
+		return "Blue Shift"
+	case VariantQuake:
+		return "Quake"
+	}
+
+	return fmt.Sprintf("<invalid: %d>", int(v))
+}
+
+// Returned when rendering the embedded textures of a Quake BSP, they use the
+// palette of the game which is not part of the BSP.
+var ErrNoTexturePalette = errors.New("textures of Quake BSPs have no palette")
 
 // BSP holds a full BSP in memory.
 type BSP struct {
 	Header
+	Variant Variant // layout used by Write, detected by Load
 
 	Entities     RawLump
-	Planes       RawLump
+	Planes       PlaneLump
 	Textures     TextureLump
-	Vertices     RawLump
+	Vertices     VertexLump
 	Visibility   RawLump
-	Nodes        RawLump
-	TexInfo      RawLump
-	Faces        RawLump
+	Nodes        NodeLump
+	TexInfo      TexInfoLump
+	Faces        FaceLump
 	Lighting     RawLump
-	ClipNodes    RawLump
-	Leaves       RawLump
-	MarkSurfaces RawLump
-	Edges        RawLump
-	SurfEdges    RawLump
-	Models       RawLump
+	ClipNodes    ClipNodeLump
+	Leaves       LeafLump
+	MarkSurfaces MarkSurfaceLump
+	Edges        EdgeLump
+	SurfEdges    SurfEdgeLump
+	Models       ModelLump
 }
 
 type Header struct {
@@ -39,8 +74,11 @@ type Header struct {
 }
 
 func (h Header) Validate() error {
-	if h.Version != BSPVersionGoldSrc {
-		return fmt.Errorf("unable to read BSP version other than %d, got: %d", BSPVersionGoldSrc, h.Version)
+	if h.Version != BSPVersionGoldSrc && h.Version != BSPVersionQuake {
+		return fmt.Errorf(
+			"unable to read BSP version other than %d or %d, got: %d",
+			BSPVersionGoldSrc, BSPVersionQuake, h.Version,
+		)
 	}
 
 	var size = int32(binary.Size(h))
@@ -62,6 +100,72 @@ func (h Header) Validate() error {
 	return nil
 }
 
+// Detects the layout using the contents of the first two lumps: entities
+// are text starting with '{', planes are binary.
+func (h Header) detectVariant(r io.ReadSeeker) (Variant, error) {
+	if h.Version == BSPVersionQuake {
+		return VariantQuake, nil
+	}
+
+	first, err := isEntityData(r, h.LumpIndex[LumpTypeEntities])
+	if err != nil {
+		return 0, err
+	}
+
+	second, err := isEntityData(r, h.LumpIndex[LumpTypePlanes])
+	if err != nil {
+		return 0, err
+	}
+
+	switch {
+	case first && !second:
+		return VariantGoldSrc, nil
+	case second && !first:
+		return VariantBlueShift, nil
+	}
+
+	// Planes can happen to start with a '{' byte, fall back on sizes.
+	// Entities can happen to be a multiple of the plane size, planes that
+	// are not can only be entities.
+	var (
+		planeSize = int32(LumpTypePlanes.EntrySize())
+		entities  = h.LumpIndex[LumpTypeEntities].Length
+		planes    = h.LumpIndex[LumpTypePlanes].Length
+	)
+	if planes%planeSize != 0 && entities%planeSize == 0 {
+		return VariantBlueShift, nil
+	}
+
+	return VariantGoldSrc, nil
+}
+
+// Returns true if the lump starts with '{', ignoring leading whitespace.
+func isEntityData(r io.ReadSeeker, entry LumpIndexEntry) (bool, error) {
+	if entry.Length <= 0 {
+		return false, nil
+	}
+
+	if _, err := r.Seek(int64(entry.Offset), io.SeekStart); err != nil {
+		return false, fmt.Errorf("unable to seek to lump start: %w", err)
+	}
+
+	buf := make([]byte, min(entry.Length, 64))
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return false, fmt.Errorf("unable to read lump start: %w", err)
+	}
+
+	return strings.HasPrefix(strings.TrimLeft(string(buf), " \t\r\n"), "{"), nil
+}
+
+// Swaps the Entities and Planes entries, converting between the GoldSrc
+// and Blue Shift layouts.
+func (h Header) swapBlueShift() Header {
+	h.LumpIndex[LumpTypeEntities], h.LumpIndex[LumpTypePlanes] =
+		h.LumpIndex[LumpTypePlanes], h.LumpIndex[LumpTypeEntities]
+
+	return h
+}
+
 func (h Header) String() string {
 	var b strings.Builder
 	fmt.Fprintln(&b, "Header:")
@@ -83,6 +187,7 @@ func (h Header) String() string {
 
 func (bsp *BSP) String() string {
 	var b strings.Builder
+	fmt.Fprintf(&b, "Variant: %s\n", bsp.Variant.String())
 	b.WriteString(bsp.Header.String())
 
 	for i, v := range bsp.Lumps() {
@@ -124,6 +229,16 @@ func Load(r io.ReadSeeker) (*BSP, error) {
 		return nil, fmt.Errorf("unable to validate header: %w", err)
 	}
 
+	variant, err := bsp.Header.detectVariant(r)
+	if err != nil {
+		return nil, fmt.Errorf("unable to detect BSP layout: %w", err)
+	}
+	bsp.Variant = variant
+	if bsp.Variant == VariantBlueShift {
+		bsp.Header = bsp.Header.swapBlueShift()
+	}
+	bsp.Textures.paletteless = bsp.Variant == VariantQuake
+
 	for i, lump := range bsp.Lumps() {
 		typ := LumpType(i)
 		if err := lump.Load(r, bsp.LumpIndex[i]); err != nil {
@@ -148,6 +263,16 @@ func LoadFromFile(path string) (*BSP, error) {
 	return Load(f)
 }
 
+// Reads a BSP from a filesystem, eg. a mod filesystem (see modfs).
+func LoadFromFS(fsys fs.FS, name string) (*BSP, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read BSP: %w", err)
+	}
+
+	return Load(bytes.NewReader(data))
+}
+
 func humanize(bytes int) string {
 	units := []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "ZiB"}
 	for i := len(units) - 1; i >= 0; i-- {
@@ -178,29 +303,34 @@ func (bsp *BSP) WriteToFile(path string) error {
 	return nil
 }
 
+// Writes the BSP keeping the lump order of the file it was loaded from.
+// Unmodified BSPs laid out the way compilers do are written back verbatim.
 func (bsp *BSP) Write(w io.WriteSeeker) error {
+	return bsp.WriteOrdered(w, bsp.LumpOrder())
+}
+
+// Writes the BSP using the given lump order, all lump offsets and lengths
+// are recomputed so any lump can grow or shrink.
+func (bsp *BSP) WriteOrdered(w io.WriteSeeker, order []LumpType) error {
+	if err := validateLumpOrder(order); err != nil {
+		return err
+	}
+
 	if _, err := w.Seek(0, io.SeekStart); err != nil {
 		return fmt.Errorf("unable to seek to start of file: %w", err)
 	}
 
 	bsp.Header = Header{Version: BSPVersionGoldSrc}
+	if bsp.Variant == VariantQuake {
+		bsp.Version = BSPVersionQuake
+	}
+	bsp.Textures.paletteless = bsp.Variant == VariantQuake
+
 	if err := binary.Write(w, binary.LittleEndian, bsp.Header); err != nil {
 		return fmt.Errorf("unable to write provisional BSP header: %w", err)
 	}
 	offset := binary.Size(bsp.Header)
 
-	//nolint:dupword // I really want you to know this is a HACK HACK HACK
-	// HACK HACK HACK HACK HACK
-	// Keep order as found in map compiled by ericw, right now only want to
-	// patch textures and not touch or move any other data to avoid breaking
-	// offsets.
-	order := []LumpType{
-		LumpTypePlanes, LumpTypeLeaves, LumpTypeVertices,
-		LumpTypeNodes, LumpTypeTexInfo, LumpTypeFaces, LumpTypeClipNodes,
-		LumpTypeMarkSurfaces, LumpTypeSurfEdges, LumpTypeEdges, LumpTypeModels,
-		LumpTypeLighting, LumpTypeVisibility, LumpTypeEntities, LumpTypeTextures,
-	}
-
 	for _, typ := range order {
 		lump := bsp.Lumps()[typ]
 		n, err := lump.Write(w)
@@ -215,6 +345,7 @@ func (bsp *BSP) Write(w io.WriteSeeker) error {
 
 		offset += n
 
+		// Compilers align lumps on 4 bytes.
 		if delta := offset % 4; delta != 0 {
 			var padding = make([]byte, 4-delta)
 			if _, err := w.Write(padding); err != nil {
@@ -224,16 +355,55 @@ func (bsp *BSP) Write(w io.WriteSeeker) error {
 		}
 	}
 
+	header := bsp.Header
+	if bsp.Variant == VariantBlueShift {
+		header = header.swapBlueShift()
+	}
+
 	if _, err := w.Seek(0, io.SeekStart); err != nil {
 		return fmt.Errorf("unable to seek to start of file to finalize header: %w", err)
 	}
-	if err := binary.Write(w, binary.LittleEndian, bsp.Header); err != nil {
+	if err := binary.Write(w, binary.LittleEndian, header); err != nil {
 		return fmt.Errorf("unable to write final BSP header: %w", err)
 	}
 
 	return nil
 }
 
+// Returns the lump types in the order their data appears in the file, lumps
+// of a BSP that was not loaded from a file are in LumpType order.
+func (h Header) LumpOrder() []LumpType {
+	order := make([]LumpType, LumpIndexSize)
+	for i := range order {
+		order[i] = LumpType(i)
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		return h.LumpIndex[order[i]].Offset < h.LumpIndex[order[j]].Offset
+	})
+
+	return order
+}
+
+func validateLumpOrder(order []LumpType) error {
+	if len(order) != int(LumpIndexSize) {
+		return fmt.Errorf("lump order must contain %d lumps, got %d", LumpIndexSize, len(order))
+	}
+
+	var seen [LumpIndexSize]bool
+	for _, typ := range order {
+		if typ < 0 || typ >= LumpIndexSize {
+			return fmt.Errorf("invalid lump in order: %s", typ.String())
+		}
+		if seen[typ] {
+			return fmt.Errorf("duplicate lump in order: %s", typ.String())
+		}
+		seen[typ] = true
+	}
+
+	return nil
+}
+
 type Limit struct {
 	Desc    string
 	Current int
@@ -0,0 +1,231 @@
+package bsp_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/L-P/goldutil/goldsrc/bsp"
+	"github.com/L-P/goldutil/goldsrc/wad"
+	"github.com/L-P/goldutil/internal/bsptest"
+)
+
+func TestRoundTrip(t *testing.T) {
+	expected := bsptest.NewRoom(t)
+	path, written := bsptest.Write(t, expected)
+
+	actual, err := bsp.LoadFromFile(path)
+	require.NoError(t, err)
+
+	for i, lump := range expected.Lumps() {
+		require.Equal(t, lump, actual.Lumps()[i], bsp.LumpType(i).String())
+	}
+
+	_, rewritten := bsptest.Write(t, actual)
+	require.Equal(t, written, rewritten)
+}
+
+func TestLoadFromFS(t *testing.T) {
+	_, written := bsptest.Write(t, bsptest.NewRoom(t))
+
+	b, err := bsp.LoadFromFS(fstest.MapFS{"maps/room.bsp": {Data: written}}, "maps/room.bsp")
+	require.NoError(t, err)
+	require.Len(t, b.Faces, 6)
+}
+
+func TestWriteKeepsLumpOrder(t *testing.T) {
+	// As found in maps compiled by ericw-tools.
+	order := []bsp.LumpType{
+		bsp.LumpTypePlanes, bsp.LumpTypeLeaves, bsp.LumpTypeVertices,
+		bsp.LumpTypeNodes, bsp.LumpTypeTexInfo, bsp.LumpTypeFaces, bsp.LumpTypeClipNodes,
+		bsp.LumpTypeMarkSurfaces, bsp.LumpTypeSurfEdges, bsp.LumpTypeEdges, bsp.LumpTypeModels,
+		bsp.LumpTypeLighting, bsp.LumpTypeVisibility, bsp.LumpTypeEntities, bsp.LumpTypeTextures,
+	}
+
+	path := filepath.Join(t.TempDir(), "ordered.bsp")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	require.NoError(t, bsptest.NewRoom(t).WriteOrdered(f, order))
+	require.NoError(t, f.Close())
+	original, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	loaded, err := bsp.LoadFromFile(path)
+	require.NoError(t, err)
+	require.Equal(t, order, loaded.LumpOrder())
+
+	_, rewritten := bsptest.Write(t, loaded)
+	require.Equal(t, original, rewritten)
+}
+
+func TestWriteKeepsTextureLayout(t *testing.T) {
+	room := bsptest.NewRoom(t)
+	order := slices.DeleteFunc(room.LumpOrder(), func(typ bsp.LumpType) bool {
+		return typ == bsp.LumpTypeTextures
+	})
+	order = append(order, bsp.LumpTypeTextures) // last, as found in compiled maps
+
+	path := filepath.Join(t.TempDir(), "layout.bsp")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	require.NoError(t, room.WriteOrdered(f, order))
+	require.NoError(t, f.Close())
+	written, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	// Lay out the textures the way the writer would not: headers in reverse
+	// order, MIP levels of the embedded texture in reverse order with
+	// padding between them.
+	var (
+		floor, wall = room.Textures.Textures[0], room.Textures.Textures[1]
+		lump        bytes.Buffer
+		header      = floor.MIPTextureHeader
+		offset      = int32(4 + 2*4)
+	)
+	wallOffset := offset
+	offset += wad.MIPTextureHeaderSize
+	floorOffset := offset
+	offset = wad.MIPTextureHeaderSize
+	for i := wad.NumMIPMaps - 1; i >= 0; i-- {
+		header.MIPOffsets[i] = offset
+		offset += int32(len(floor.MIPData[i])) + 4
+	}
+
+	require.NoError(t, binary.Write(&lump, binary.LittleEndian, []int32{2, floorOffset, wallOffset}))
+	require.NoError(t, binary.Write(&lump, binary.LittleEndian, wall.MIPTextureHeader))
+	require.NoError(t, binary.Write(&lump, binary.LittleEndian, header))
+	for i := wad.NumMIPMaps - 1; i >= 0; i-- {
+		lump.Write(floor.MIPData[i])
+		lump.Write(make([]byte, 4))
+	}
+	require.NoError(t, binary.Write(&lump, binary.LittleEndian, floor.PaletteSize))
+	require.NoError(t, binary.Write(&lump, binary.LittleEndian, floor.Palette))
+	lump.Write(make([]byte, 2))
+
+	index := room.LumpIndex
+	index[bsp.LumpTypeTextures].Length = int32(lump.Len())
+	var original bytes.Buffer
+	require.NoError(t, binary.Write(&original, binary.LittleEndian, bsp.Header{Version: bsp.BSPVersionGoldSrc, LumpIndex: index}))
+	original.Write(written[original.Len():index[bsp.LumpTypeTextures].Offset])
+	original.Write(lump.Bytes())
+	require.NoError(t, os.WriteFile(path, original.Bytes(), 0o600))
+
+	loaded, err := bsp.LoadFromFile(path)
+	require.NoError(t, err)
+	require.Equal(t, floor.MIPData, loaded.Textures.Textures[0].MIPData)
+	require.Equal(t, []int32{floorOffset, wallOffset}, loaded.Textures.Offsets)
+
+	_, rewritten := bsptest.Write(t, loaded)
+	require.Equal(t, original.Bytes(), rewritten)
+
+	// Changing a texture lays the lump out again.
+	loaded.Textures.Textures[0].MIPData[3][0] = 1
+	_, rewritten = bsptest.Write(t, loaded)
+	require.NotEqual(t, original.Len(), len(rewritten))
+	reloaded, err := bsp.Load(bytes.NewReader(rewritten))
+	require.NoError(t, err)
+	require.Equal(t, []int32{12, 12 + floor.Size()}, reloaded.Textures.Offsets)
+	require.Equal(t, uint8(1), reloaded.Textures.Textures[0].MIPData[3][0])
+}
+
+func TestWriteRelocatesLumps(t *testing.T) {
+	expected := bsptest.NewRoom(t)
+	path, _ := bsptest.Write(t, expected)
+	originalOrder := expected.LumpOrder()
+
+	modified, err := bsp.LoadFromFile(path)
+	require.NoError(t, err)
+
+	// Grow and shrink lumps in the middle of the file, change their padding.
+	modified.Entities = append(bytes.TrimRight(modified.Entities, "\x00"), []byte("{\n\"classname\" \"info_null\"\n}\n\x00")...)
+	modified.Lighting = modified.Lighting[:len(modified.Lighting)-3]
+	tex, err := wad.NewMIPTexture("new", 32, 32)
+	require.NoError(t, err)
+	require.NoError(t, tex.SetData(make([]byte, 32*32)))
+	modified.Textures.Textures = append(modified.Textures.Textures, tex)
+
+	path, _ = bsptest.Write(t, modified)
+	actual, err := bsp.LoadFromFile(path)
+	require.NoError(t, err)
+
+	require.Equal(t, originalOrder, actual.LumpOrder())
+	for i, lump := range modified.Lumps() {
+		require.Equal(t, lump, actual.Lumps()[i], bsp.LumpType(i).String())
+	}
+	for i, entry := range actual.LumpIndex {
+		require.Zero(t, entry.Offset%4, "%s is aligned", bsp.LumpType(i).String())
+	}
+}
+
+func TestBlueShift(t *testing.T) {
+	expected := bsptest.NewRoom(t)
+	expected.Variant = bsp.VariantBlueShift
+	path, written := bsptest.Write(t, expected)
+
+	var header bsp.Header
+	require.NoError(t, binary.Read(bytes.NewReader(written), binary.LittleEndian, &header))
+	require.Equal(t, int32(bsp.BSPVersionGoldSrc), header.Version)
+	require.Equal(t, expected.LumpIndex[bsp.LumpTypePlanes], header.LumpIndex[bsp.LumpTypeEntities])
+	require.Equal(t, expected.LumpIndex[bsp.LumpTypeEntities], header.LumpIndex[bsp.LumpTypePlanes])
+
+	actual, err := bsp.LoadFromFile(path)
+	require.NoError(t, err)
+	require.Equal(t, bsp.VariantBlueShift, actual.Variant)
+	require.Equal(t, expected.Entities, actual.Entities)
+	require.Equal(t, expected.Planes, actual.Planes)
+
+	_, rewritten := bsptest.Write(t, actual)
+	require.Equal(t, written, rewritten)
+}
+
+func TestBlueShiftAlignedEntities(t *testing.T) {
+	// Both lumps are a multiple of the plane size, the layout can only be
+	// told apart by content.
+	for _, variant := range []bsp.Variant{bsp.VariantGoldSrc, bsp.VariantBlueShift} {
+		expected := bsptest.NewRoom(t)
+		expected.Variant = variant
+		entities := bytes.TrimRight(expected.Entities, "\x00")
+		for (len(entities)+1)%bsp.LumpTypePlanes.EntrySize() != 0 {
+			entities = append(entities, '\n')
+		}
+		expected.Entities = append(entities, 0)
+		path, _ := bsptest.Write(t, expected)
+
+		actual, err := bsp.LoadFromFile(path)
+		require.NoError(t, err, variant.String())
+		require.Equal(t, variant, actual.Variant)
+		require.Equal(t, expected.Entities, actual.Entities)
+		require.Equal(t, expected.Planes, actual.Planes)
+	}
+}
+
+func TestQuake(t *testing.T) {
+	expected := bsptest.NewRoom(t)
+	expected.Variant = bsp.VariantQuake
+	path, written := bsptest.Write(t, expected)
+	require.Equal(t, int32(bsp.BSPVersionQuake), expected.Version)
+
+	actual, err := bsp.LoadFromFile(path)
+	require.NoError(t, err)
+	require.Equal(t, bsp.VariantQuake, actual.Variant)
+
+	// Embedded textures are written without a palette.
+	floor := expected.Textures.Textures[0]
+	require.Equal(t, floor.MIPData, actual.Textures.Textures[0].MIPData)
+	require.Equal(t, int(4+2*4+floor.PalettelessSize()+wad.MIPTextureHeaderSize), int(actual.LumpIndex[bsp.LumpTypeTextures].Length))
+
+	_, rewritten := bsptest.Write(t, actual)
+	require.Equal(t, written, rewritten)
+
+	// Lighting is grayscale.
+	actual.Lighting = bytes.Repeat([]byte{0x80}, len(actual.Lighting))
+	lightmaps, err := actual.FaceLightmaps(4)
+	require.NoError(t, err)
+	require.Equal(t, []uint8{0x80, 0x80, 0x80, 0xFF}, lightmaps[0].Image.Pix[:4])
+}
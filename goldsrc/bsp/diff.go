@@ -0,0 +1,338 @@
+package bsp
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/L-P/goldutil/goldsrc/qmap"
+	"github.com/L-P/goldutil/goldsrc/wad"
+)
+
+// Differences between two BSPs.
+type Diff struct {
+	Entities []EntityDiff
+	Textures TextureDiff
+	Lumps    []LumpDiff  // all lumps, in LumpType order
+	Limits   []LimitDiff // only the limits that changed
+}
+
+// An entity that was added, removed, or had its KVs changed.
+// Entities are matched by targetname, or by classname and origin when they
+// have no targetname.
+type EntityDiff struct {
+	Name     string
+	Old, New map[string]string // Old is nil for added entities, New for removed ones
+}
+
+type KVChange struct {
+	Key      string
+	Old, New string
+}
+
+// Returns the changed KVs sorted by key.
+func (diff EntityDiff) Changes() []KVChange {
+	var ret []KVChange
+	keys := slices.Collect(maps.Keys(diff.Old))
+	for k := range diff.New {
+		if _, ok := diff.Old[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+
+	for _, k := range keys {
+		if diff.Old[k] != diff.New[k] {
+			ret = append(ret, KVChange{Key: k, Old: diff.Old[k], New: diff.New[k]})
+		}
+	}
+
+	return ret
+}
+
+// Texture names are compared ignoring case.
+type TextureDiff struct {
+	Added, Removed []string
+	Changed        []string // size, embedding, palette, or pixels differ
+}
+
+// Count is the number of entries, -1 for lumps storing raw bytes.
+type LumpDiff struct {
+	Type               LumpType
+	OldSize, NewSize   int
+	OldCount, NewCount int
+	Changed            bool // contents differ, even if the size is the same
+}
+
+type LimitDiff struct {
+	Desc     string
+	Old, New int
+	Max      int
+}
+
+// Compares two BSPs. Lump sizes and limits are read from the lump index and
+// are only meaningful for BSPs that were loaded or written.
+func NewDiff(a, b *BSP) (Diff, error) {
+	entA, err := a.LoadEntities()
+	if err != nil {
+		return Diff{}, fmt.Errorf("unable to load entities of first BSP: %w", err)
+	}
+
+	entB, err := b.LoadEntities()
+	if err != nil {
+		return Diff{}, fmt.Errorf("unable to load entities of second BSP: %w", err)
+	}
+
+	lumps, err := diffLumps(a, b, countEntities(entA), countEntities(entB))
+	if err != nil {
+		return Diff{}, fmt.Errorf("unable to compare lumps: %w", err)
+	}
+
+	return Diff{
+		Entities: diffEntities(entA, entB),
+		Textures: diffTextures(a.Textures.Textures, b.Textures.Textures),
+		Lumps:    lumps,
+		Limits:   diffLimits(a.Limits(), b.Limits()),
+	}, nil
+}
+
+// Returns true if the diff has no entity, texture, or lump difference.
+func (diff Diff) IsEmpty() bool {
+	if len(diff.Entities) > 0 ||
+		len(diff.Textures.Added) > 0 ||
+		len(diff.Textures.Removed) > 0 ||
+		len(diff.Textures.Changed) > 0 {
+		return false
+	}
+
+	for _, lump := range diff.Lumps {
+		if lump.Changed {
+			return false
+		}
+	}
+
+	return true
+}
+
+type keyedEntity struct {
+	key, name string
+	kvs       map[string]string
+}
+
+func diffEntities(a, b *qmap.QMap) []EntityDiff {
+	var (
+		ret       []EntityDiff
+		newEnts   = keyEntities(b)
+		matched   = make([]bool, len(newEnts))
+		remaining = map[string][]int{} // key to unmatched indexes in newEnts
+	)
+
+	// Entities sharing a key are matched in order of appearance.
+	for i, ent := range newEnts {
+		remaining[ent.key] = append(remaining[ent.key], i)
+	}
+
+	for _, old := range keyEntities(a) {
+		candidates := remaining[old.key]
+		if len(candidates) == 0 {
+			ret = append(ret, EntityDiff{Name: old.name, Old: old.kvs})
+			continue
+		}
+
+		cur := newEnts[candidates[0]]
+		matched[candidates[0]] = true
+		remaining[old.key] = candidates[1:]
+		if !maps.Equal(old.kvs, cur.kvs) {
+			ret = append(ret, EntityDiff{Name: cur.name, Old: old.kvs, New: cur.kvs})
+		}
+	}
+
+	for i, ent := range newEnts {
+		if !matched[i] {
+			ret = append(ret, EntityDiff{Name: ent.name, New: ent.kvs})
+		}
+	}
+
+	return ret
+}
+
+func keyEntities(qm *qmap.QMap) []keyedEntity {
+	var ret []keyedEntity
+	for ent := range qm.Entities() {
+		var (
+			class = ent.KVs["classname"]
+			k     = keyedEntity{kvs: ent.KVs}
+		)
+
+		if name := ent.KVs["targetname"]; name != "" {
+			k.key = "targetname\x00" + name
+			k.name = class + " " + name
+		} else {
+			k.key = "classname\x00" + class + "\x00" + ent.KVs["origin"]
+			k.name = class
+			if origin := ent.KVs["origin"]; origin != "" {
+				k.name += " at " + origin
+			}
+		}
+
+		ret = append(ret, k)
+	}
+
+	return ret
+}
+
+func countEntities(qm *qmap.QMap) int {
+	var ret int
+	for range qm.Entities() {
+		ret++
+	}
+
+	return ret
+}
+
+func diffTextures(a, b []wad.MIPTexture) TextureDiff {
+	var (
+		ret  TextureDiff
+		oldT = map[string]wad.MIPTexture{}
+		seen = map[string]bool{}
+	)
+	for _, tex := range a {
+		oldT[strings.ToUpper(tex.Name.String())] = tex
+	}
+
+	for _, tex := range b {
+		name := strings.ToUpper(tex.Name.String())
+		seen[name] = true
+
+		old, ok := oldT[name]
+		switch {
+		case !ok:
+			ret.Added = append(ret.Added, tex.Name.String())
+		case !sameTexture(old, tex):
+			ret.Changed = append(ret.Changed, tex.Name.String())
+		}
+	}
+
+	for _, tex := range a {
+		if !seen[strings.ToUpper(tex.Name.String())] {
+			ret.Removed = append(ret.Removed, tex.Name.String())
+		}
+	}
+
+	return ret
+}
+
+func sameTexture(a, b wad.MIPTexture) bool {
+	if a.Width != b.Width || a.Height != b.Height || a.IsEmbedded() != b.IsEmbedded() {
+		return false
+	}
+
+	for i := range a.MIPData {
+		if !bytes.Equal(a.MIPData[i], b.MIPData[i]) {
+			return false
+		}
+	}
+
+	return a.PaletteSize == b.PaletteSize && a.Palette == b.Palette
+}
+
+func diffLumps(a, b *BSP, entitiesA, entitiesB int) ([]LumpDiff, error) {
+	ret := make([]LumpDiff, LumpIndexSize)
+	for i := range ret {
+		typ := LumpType(i)
+		digestA, err := a.lumpDigest(typ)
+		if err != nil {
+			return nil, err
+		}
+
+		digestB, err := b.lumpDigest(typ)
+		if err != nil {
+			return nil, err
+		}
+
+		ret[i] = LumpDiff{
+			Type:     typ,
+			OldSize:  int(a.LumpIndex[typ].Length),
+			NewSize:  int(b.LumpIndex[typ].Length),
+			OldCount: a.lumpCount(typ),
+			NewCount: b.lumpCount(typ),
+			Changed:  digestA != digestB,
+		}
+	}
+
+	ret[LumpTypeEntities].OldCount = entitiesA
+	ret[LumpTypeEntities].NewCount = entitiesB
+
+	return ret, nil
+}
+
+// Hashes the contents of a lump as they would be written.
+func (bsp *BSP) lumpDigest(typ LumpType) ([sha256.Size]byte, error) {
+	if typ == LumpTypeTextures {
+		return bsp.Textures.texturesDigest()
+	}
+
+	var (
+		ret [sha256.Size]byte
+		h   = sha256.New()
+	)
+	if err := binary.Write(h, binary.LittleEndian, bsp.Lumps()[typ]); err != nil {
+		return ret, fmt.Errorf("unable to hash %s lump: %w", typ, err)
+	}
+	h.Sum(ret[:0])
+
+	return ret, nil
+}
+
+// Returns the number of entries in a lump, -1 for raw lumps.
+func (bsp *BSP) lumpCount(typ LumpType) int {
+	switch typ {
+	case LumpTypePlanes:
+		return len(bsp.Planes)
+	case LumpTypeTextures:
+		return len(bsp.Textures.Textures)
+	case LumpTypeVertices:
+		return len(bsp.Vertices)
+	case LumpTypeNodes:
+		return len(bsp.Nodes)
+	case LumpTypeTexInfo:
+		return len(bsp.TexInfo)
+	case LumpTypeFaces:
+		return len(bsp.Faces)
+	case LumpTypeClipNodes:
+		return len(bsp.ClipNodes)
+	case LumpTypeLeaves:
+		return len(bsp.Leaves)
+	case LumpTypeMarkSurfaces:
+		return len(bsp.MarkSurfaces)
+	case LumpTypeEdges:
+		return len(bsp.Edges)
+	case LumpTypeSurfEdges:
+		return len(bsp.SurfEdges)
+	case LumpTypeModels:
+		return len(bsp.Models)
+	case LumpTypeEntities, LumpTypeVisibility, LumpTypeLighting, LumpIndexSize:
+	}
+
+	return -1
+}
+
+func diffLimits(a, b []Limit) []LimitDiff {
+	old := map[string]Limit{}
+	for _, v := range a {
+		old[v.Desc] = v
+	}
+
+	var ret []LimitDiff
+	for _, v := range b {
+		if prev := old[v.Desc]; prev.Current != v.Current {
+			ret = append(ret, LimitDiff{Desc: v.Desc, Old: prev.Current, New: v.Current, Max: v.Max})
+		}
+	}
+
+	return ret
+}
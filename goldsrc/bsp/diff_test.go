@@ -0,0 +1,117 @@
+package bsp_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/L-P/goldutil/goldsrc/bsp"
+	"github.com/L-P/goldutil/goldsrc/wad"
+	"github.com/L-P/goldutil/internal/bsptest"
+)
+
+func TestDiff(t *testing.T) {
+	path, _ := bsptest.Write(t, bsptest.NewRoom(t))
+	a, err := bsp.LoadFromFile(path)
+	require.NoError(t, err)
+
+	same, err := bsp.NewDiff(a, a)
+	require.NoError(t, err)
+	require.True(t, same.IsEmpty())
+	require.Empty(t, same.Limits)
+
+	modified := bsptest.NewRoom(t)
+	modified.Entities = bsp.RawLump("{\n" +
+		`"classname" "worldspawn"` + "\n" +
+		`"wad" "test.wad"` + "\n" +
+		"}\n{\n" +
+		`"classname" "info_player_start"` + "\n" +
+		`"origin" "64 64 36"` + "\n" +
+		"}\n{\n" +
+		`"classname" "light"` + "\n" +
+		`"targetname" "lamp"` + "\n" +
+		"}\n\x00")
+	require.NoError(t, modified.Textures.Textures[0].SetData(make([]byte, 16*16)))
+	modified.Textures.Textures[0].MIPData[0][0] = 1
+	tex, err := wad.NewMIPTexture("new", 16, 16)
+	require.NoError(t, err)
+	modified.Textures.Textures = append(modified.Textures.Textures, tex)
+	modified.Planes = append(modified.Planes, bsp.Plane{})
+
+	path, _ = bsptest.Write(t, modified)
+	b, err := bsp.LoadFromFile(path)
+	require.NoError(t, err)
+
+	diff, err := bsp.NewDiff(a, b)
+	require.NoError(t, err)
+	require.False(t, diff.IsEmpty())
+
+	require.Len(t, diff.Entities, 4)
+	require.Equal(t, "worldspawn", diff.Entities[0].Name)
+	require.Equal(t, []bsp.KVChange{{
+		Key: "wad",
+		Old: `\half-life\valve\halflife.wad;test.wad`,
+		New: "test.wad",
+	}}, diff.Entities[0].Changes())
+	require.Equal(t, "info_player_start at 128 128 36", diff.Entities[1].Name)
+	require.Nil(t, diff.Entities[1].New)
+	require.Equal(t, "info_player_start at 64 64 36", diff.Entities[2].Name)
+	require.Nil(t, diff.Entities[2].Old)
+	require.Equal(t, "light lamp", diff.Entities[3].Name)
+
+	require.Equal(t, bsp.TextureDiff{Added: []string{"new"}, Changed: []string{"floor"}}, diff.Textures)
+
+	require.Len(t, diff.Lumps, int(bsp.LumpIndexSize))
+	planes := diff.Lumps[bsp.LumpTypePlanes]
+	require.Equal(t, planes.OldCount+1, planes.NewCount)
+	require.Equal(t, planes.OldSize+bsp.LumpTypePlanes.EntrySize(), planes.NewSize)
+	entities := diff.Lumps[bsp.LumpTypeEntities]
+	require.Equal(t, []int{2, 3}, []int{entities.OldCount, entities.NewCount})
+	require.Equal(t, -1, diff.Lumps[bsp.LumpTypeLighting].NewCount)
+
+	require.Contains(t, diff.Limits, bsp.LimitDiff{
+		Desc: "Planes",
+		Old:  planes.OldCount,
+		New:  planes.NewCount,
+		Max:  bsp.MaxMapPlanes,
+	})
+}
+
+func TestDiffSameSize(t *testing.T) {
+	path, _ := bsptest.Write(t, bsptest.NewRoom(t))
+	a, err := bsp.LoadFromFile(path)
+	require.NoError(t, err)
+
+	modified := bsptest.NewRoom(t)
+	modified.Vertices[0].X += 8
+	modified.TexInfo[0].Flags |= bsp.TexInfoFlagSpecial
+	modified.Lighting[0]++
+	modified.Textures.Textures[0].MIPData[2][0] = 1
+	modified.Textures.Textures[0].Palette[1].R = 1
+
+	path, _ = bsptest.Write(t, modified)
+	b, err := bsp.LoadFromFile(path)
+	require.NoError(t, err)
+
+	diff, err := bsp.NewDiff(a, b)
+	require.NoError(t, err)
+	require.False(t, diff.IsEmpty())
+	require.Equal(t, []string{"floor"}, diff.Textures.Changed)
+
+	for _, lump := range diff.Lumps {
+		require.Equal(t, lump.OldSize, lump.NewSize, lump.Type.String())
+		switch lump.Type {
+		case bsp.LumpTypeVertices, bsp.LumpTypeTexInfo, bsp.LumpTypeLighting, bsp.LumpTypeTextures:
+			require.True(t, lump.Changed, lump.Type.String())
+		default:
+			require.False(t, lump.Changed, lump.Type.String())
+		}
+	}
+
+	// Only the palette.
+	modified = bsptest.NewRoom(t)
+	modified.Textures.Textures[0].Palette[1].R = 1
+	diff, err = bsp.NewDiff(bsptest.NewRoom(t), modified)
+	require.NoError(t, err)
+	require.Equal(t, []string{"floor"}, diff.Textures.Changed)
+}
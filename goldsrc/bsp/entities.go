@@ -0,0 +1,127 @@
+package bsp
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/L-P/goldutil/goldsrc/qmap"
+)
+
+// Parses the entity lump.
+func (bsp *BSP) LoadEntities() (*qmap.QMap, error) {
+	qm, err := qmap.LoadFromReader(bytes.NewReader(bsp.EntitiesText()))
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse entity lump: %w", err)
+	}
+
+	return qm, nil
+}
+
+// Replaces the entity lump contents with the given entities.
+func (bsp *BSP) SetEntities(qm *qmap.QMap) error {
+	data, err := qm.MarshalEntities()
+	if err != nil {
+		return fmt.Errorf("unable to marshal entities: %w", err)
+	}
+
+	bsp.Entities = append(data, 0)
+
+	return nil
+}
+
+// Returns the raw entity lump text, ripent .ent files use the same format.
+func (bsp *BSP) EntitiesText() []byte {
+	// The lump is a NUL-terminated string.
+	return bytes.TrimRight(bsp.Entities, "\x00")
+}
+
+// Replaces the entity lump with the given ripent-style text after ensuring
+// it parses and can be used by the BSP. The text is stored verbatim.
+func (bsp *BSP) ImportEntities(r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("unable to read entities: %w", err)
+	}
+	data = bytes.TrimRight(data, "\x00")
+
+	qm, err := qmap.LoadFromReader(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("unable to parse entities: %w", err)
+	}
+
+	if err := bsp.validateEntities(qm); err != nil {
+		return err
+	}
+
+	bsp.Entities = append(data, 0)
+
+	return nil
+}
+
+func (bsp *BSP) validateEntities(qm *qmap.QMap) error {
+	var (
+		errs []error
+		i    int
+	)
+
+	for ent := range qm.Entities() {
+		class := ent.KVs["classname"]
+		if i == 0 && class != "worldspawn" {
+			errs = append(errs, fmt.Errorf("entity #%d (%s): first entity must be worldspawn", i, class))
+		}
+
+		if len(ent.Brushes) > 0 {
+			errs = append(errs, fmt.Errorf("entity #%d (%s): brushes cannot be imported in a compiled map", i, class))
+		}
+
+		if model, ok := strings.CutPrefix(ent.KVs["model"], "*"); ok {
+			n, err := strconv.Atoi(model)
+			if err != nil || n < 1 || n >= len(bsp.Models) {
+				errs = append(errs, fmt.Errorf(
+					"entity #%d (%s): model *%s does not exist, BSP has %d brush models",
+					i, class, model, len(bsp.Models)-1,
+				))
+			}
+		}
+
+		i++
+	}
+
+	return errors.Join(errs...)
+}
+
+// Returns the base names of the WADs listed in the worldspawn "wad" key, in
+// the order the engine searches them. Compilers store whatever path the
+// editor used, only the file name is meaningful to the engine.
+func (bsp *BSP) WADNames() ([]string, error) {
+	qm, err := bsp.LoadEntities()
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []string
+	for ent := range qm.Entities() {
+		if ent.KVs["classname"] != "worldspawn" {
+			return nil, errors.New("first entity is not worldspawn")
+		}
+
+		for path := range strings.SplitSeq(ent.KVs["wad"], ";") {
+			path = strings.TrimSpace(path)
+			if i := strings.LastIndexAny(path, `/\`); i >= 0 {
+				path = path[i+1:]
+			}
+
+			if path != "" {
+				ret = append(ret, path)
+			}
+		}
+
+		break
+	}
+
+	return ret, nil
+}
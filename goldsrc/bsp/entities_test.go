@@ -0,0 +1,73 @@
+package bsp_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/L-P/goldutil/goldsrc/bsp"
+	"github.com/L-P/goldutil/internal/bsptest"
+)
+
+func TestEntitiesRoundTrip(t *testing.T) {
+	original := bsptest.NewRoom(t)
+	qm, err := original.LoadEntities()
+	require.NoError(t, err)
+
+	require.NoError(t, original.SetEntities(qm))
+	require.Equal(t, bsptest.NewRoom(t).Entities, original.Entities, "unmodified entities are written verbatim")
+
+	starts := qm.FindByKV("classname", "info_player_start")
+	require.Len(t, starts, 1)
+	starts[0].Entity.KVs["angles"] = "0 90 0"
+	starts[0].Entity.KVs["classname"] = "info_player_deathmatch"
+	require.NoError(t, original.SetEntities(qm))
+
+	path, _ := bsptest.Write(t, original)
+	reloaded, err := bsp.LoadFromFile(path)
+	require.NoError(t, err)
+
+	require.Equal(t, "{\n"+
+		`"classname" "worldspawn"`+"\n"+
+		`"wad" "\half-life\valve\halflife.wad;test.wad"`+"\n"+
+		"}\n{\n"+
+		`"classname" "info_player_deathmatch"`+"\n"+
+		`"origin" "128 128 36"`+"\n"+
+		`"angles" "0 90 0"`+"\n"+
+		"}\n\x00",
+		string(reloaded.Entities),
+	)
+}
+
+func TestImportEntities(t *testing.T) {
+	for name, c := range map[string]struct {
+		input string
+		err   string
+	}{
+		"valid":         {input: "{\n\"classname\" \"worldspawn\"\n}\n"},
+		"unparsable":    {input: "{\n\"classname\"\n}\n", err: "unable to parse entities"},
+		"no worldspawn": {input: "{\n\"classname\" \"info_null\"\n}\n", err: "first entity must be worldspawn"},
+		"brushes": {
+			input: "{\n\"classname\" \"worldspawn\"\n{\n( 0 0 0 ) ( 0 1 0 ) ( 1 0 0 ) null [ 1 0 0 0 ] [ 0 -1 0 0 ] 0 1 1\n}\n}\n",
+			err:   "brushes cannot be imported",
+		},
+		"missing model": {
+			input: "{\n\"classname\" \"worldspawn\"\n}\n{\n\"classname\" \"func_wall\"\n\"model\" \"*1\"\n}\n",
+			err:   "model *1 does not exist",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			b := bsptest.NewRoom(t)
+			err := b.ImportEntities(strings.NewReader(c.input))
+			if c.err != "" {
+				require.ErrorContains(t, err, c.err)
+				require.Equal(t, bsptest.NewRoom(t).Entities, b.Entities, "entities are left untouched on error")
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, c.input+"\x00", string(b.Entities))
+		})
+	}
+}
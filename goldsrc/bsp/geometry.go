@@ -0,0 +1,97 @@
+package bsp
+
+import (
+	"fmt"
+
+	"github.com/L-P/goldutil/goldsrc/wad"
+)
+
+// Returns the vertices of a face in winding order, clockwise when seen from
+// the front.
+func (bsp *BSP) FaceVertices(index int) ([]Vec3f, error) {
+	if index < 0 || index >= len(bsp.Faces) {
+		return nil, fmt.Errorf("face %d does not exist", index)
+	}
+
+	var (
+		face = bsp.Faces[index]
+		ret  = make([]Vec3f, 0, face.NumEdges)
+	)
+	for i := face.FirstEdge; i < face.FirstEdge+int32(face.NumEdges); i++ {
+		if i < 0 || int(i) >= len(bsp.SurfEdges) {
+			return nil, fmt.Errorf("face %d: surfedge %d does not exist", index, i)
+		}
+
+		// Negative surfedges use the edge backwards. Widened so negating
+		// MinInt32 does not overflow.
+		var edge, side = int64(bsp.SurfEdges[i]), 0
+		if edge < 0 {
+			edge, side = -edge, 1
+		}
+		if edge >= int64(len(bsp.Edges)) {
+			return nil, fmt.Errorf("face %d: edge %d does not exist", index, edge)
+		}
+
+		vertex := bsp.Edges[edge][side]
+		if int(vertex) >= len(bsp.Vertices) {
+			return nil, fmt.Errorf("face %d: vertex %d does not exist", index, vertex)
+		}
+
+		ret = append(ret, bsp.Vertices[vertex])
+	}
+
+	return ret, nil
+}
+
+// Returns the normal of a face, pointing toward its front.
+func (bsp *BSP) FaceNormal(index int) (Vec3f, error) {
+	if index < 0 || index >= len(bsp.Faces) {
+		return Vec3f{}, fmt.Errorf("face %d does not exist", index)
+	}
+
+	face := bsp.Faces[index]
+	if int(face.Plane) >= len(bsp.Planes) {
+		return Vec3f{}, fmt.Errorf("face %d: plane %d does not exist", index, face.Plane)
+	}
+
+	normal := bsp.Planes[face.Plane].Normal
+	if face.Side != 0 {
+		return normal.Scale(-1), nil
+	}
+
+	return normal, nil
+}
+
+// Returns the texture applied to a face. Textures stored in external WADs
+// only have their header set.
+func (bsp *BSP) FaceTexture(index int) (wad.MIPTexture, error) {
+	texInfo, err := bsp.faceTexInfo(index)
+	if err != nil {
+		return wad.MIPTexture{}, err
+	}
+
+	if texInfo.MIPTex < 0 || int(texInfo.MIPTex) >= len(bsp.Textures.Textures) {
+		return wad.MIPTexture{}, fmt.Errorf("face %d: texture %d does not exist", index, texInfo.MIPTex)
+	}
+
+	return bsp.Textures.Textures[texInfo.MIPTex], nil
+}
+
+func (bsp *BSP) faceTexInfo(index int) (TexInfo, error) {
+	if index < 0 || index >= len(bsp.Faces) {
+		return TexInfo{}, fmt.Errorf("face %d does not exist", index)
+	}
+
+	face := bsp.Faces[index]
+	if face.TexInfo < 0 || int(face.TexInfo) >= len(bsp.TexInfo) {
+		return TexInfo{}, fmt.Errorf("face %d: texinfo %d does not exist", index, face.TexInfo)
+	}
+
+	return bsp.TexInfo[face.TexInfo], nil
+}
+
+// Returns the texture coordinates of a point, in texels.
+func (texInfo TexInfo) TexCoords(point Vec3f) (float32, float32) {
+	return point.Dot(texInfo.S.Vec) + texInfo.S.Offset,
+		point.Dot(texInfo.T.Vec) + texInfo.T.Offset
+}
@@ -0,0 +1,50 @@
+package bsp_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/L-P/goldutil/goldsrc/bsp"
+	"github.com/L-P/goldutil/internal/bsptest"
+)
+
+func TestFaceGeometry(t *testing.T) {
+	b := bsptest.NewRoom(t)
+
+	// Face 4 is the floor, seen from above.
+	vertices, err := b.FaceVertices(4)
+	require.NoError(t, err)
+	require.Equal(t, []bsp.Vec3f{
+		{X: 0, Y: 0}, {X: 0, Y: 256}, {X: 256, Y: 256}, {X: 256, Y: 0},
+	}, vertices)
+
+	normal, err := b.FaceNormal(4)
+	require.NoError(t, err)
+	require.Equal(t, bsp.Vec3f{Z: 1}, normal)
+
+	normal, err = b.FaceNormal(5)
+	require.NoError(t, err)
+	require.Equal(t, bsp.Vec3f{Z: -1}, normal, "ceiling faces down")
+
+	tex, err := b.FaceTexture(4)
+	require.NoError(t, err)
+	require.Equal(t, "floor", tex.Name.String())
+
+	s, u := b.TexInfo[b.Faces[4].TexInfo].TexCoords(bsp.Vec3f{X: 16, Y: 32, Z: 64})
+	require.Equal(t, []float32{16, -32}, []float32{s, u})
+
+	_, err = b.FaceVertices(len(b.Faces))
+	require.Error(t, err)
+
+	b.SurfEdges[b.Faces[4].FirstEdge] = math.MinInt32
+	_, err = b.FaceVertices(4)
+	require.Error(t, err)
+}
+
+func TestWADNames(t *testing.T) {
+	names, err := bsptest.NewRoom(t).WADNames()
+	require.NoError(t, err)
+	require.Equal(t, []string{"halflife.wad", "test.wad"}, names)
+}
@@ -0,0 +1,186 @@
+package bsp
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Collision hulls of the world model, hull 0 is the point hull built from
+// nodes and leaves, hulls 1 to 3 are built from clipnodes.
+type hull struct {
+	bsp   *BSP
+	index int
+}
+
+func (bsp *BSP) hull(index int) (hull, error) {
+	if index < 0 || index >= MaxMapHulls {
+		return hull{}, fmt.Errorf("hull %d does not exist", index)
+	}
+
+	if len(bsp.Models) == 0 {
+		return hull{}, errors.New("BSP has no world model")
+	}
+
+	return hull{bsp: bsp, index: index}, nil
+}
+
+func (h hull) headNode() int {
+	return int(h.bsp.Models[0].HeadNodes[h.index])
+}
+
+// Returns the plane and children of a node, negative children are leaves
+// in hull 0 and contents in other hulls.
+func (h hull) node(index int) (Plane, [2]int, error) {
+	var (
+		plane    int32
+		children [2]int16
+	)
+
+	if h.index == 0 {
+		if index >= len(h.bsp.Nodes) {
+			return Plane{}, [2]int{}, fmt.Errorf("node %d does not exist", index)
+		}
+		plane, children = h.bsp.Nodes[index].Plane, h.bsp.Nodes[index].Children
+	} else {
+		if index >= len(h.bsp.ClipNodes) {
+			return Plane{}, [2]int{}, fmt.Errorf("clipnode %d does not exist", index)
+		}
+		plane, children = h.bsp.ClipNodes[index].Plane, h.bsp.ClipNodes[index].Children
+	}
+
+	if plane < 0 || int(plane) >= len(h.bsp.Planes) {
+		return Plane{}, [2]int{}, fmt.Errorf("plane %d does not exist", plane)
+	}
+
+	return h.bsp.Planes[plane], [2]int{int(children[0]), int(children[1])}, nil
+}
+
+// Returns the contents of a negative node child.
+func (h hull) contents(child int) (Contents, error) {
+	if h.index != 0 {
+		return Contents(child), nil
+	}
+
+	leaf := -child - 1
+	if leaf >= len(h.bsp.Leaves) {
+		return 0, fmt.Errorf("leaf %d does not exist", leaf)
+	}
+
+	return h.bsp.Leaves[leaf].Contents, nil
+}
+
+// Returns the negative child containing the point.
+func (h hull) find(point Vec3f) (int, error) {
+	var node = h.headNode()
+
+	// Bounded so malformed trees cannot loop forever.
+	for range len(h.bsp.Nodes) + len(h.bsp.ClipNodes) + 1 {
+		if node < 0 {
+			return node, nil
+		}
+
+		plane, children, err := h.node(node)
+		if err != nil {
+			return 0, err
+		}
+
+		if point.Dot(plane.Normal)-plane.Dist < 0 {
+			node = children[1]
+		} else {
+			node = children[0]
+		}
+	}
+
+	return 0, errors.New("BSP tree contains a loop")
+}
+
+// Returns the world leaf containing a point.
+func (bsp *BSP) PointLeaf(point Vec3f) (int, error) {
+	h, err := bsp.hull(0)
+	if err != nil {
+		return 0, err
+	}
+
+	child, err := h.find(point)
+	if err != nil {
+		return 0, err
+	}
+
+	return -child - 1, nil
+}
+
+// Returns the contents of the world at a point for the given hull.
+// In hulls 1 to 3 the point is the center of the player or monster bounding
+// box.
+func (bsp *BSP) PointContents(hullIndex int, point Vec3f) (Contents, error) {
+	h, err := bsp.hull(hullIndex)
+	if err != nil {
+		return 0, err
+	}
+
+	child, err := h.find(point)
+	if err != nil {
+		return 0, err
+	}
+
+	return h.contents(child)
+}
+
+// Returns whether a line between two points crosses solid world geometry in
+// the given hull.
+func (bsp *BSP) TraceBlocked(hullIndex int, start, end Vec3f) (bool, error) {
+	h, err := bsp.hull(hullIndex)
+	if err != nil {
+		return false, err
+	}
+
+	return h.traceBlocked(h.headNode(), start, end, 0)
+}
+
+func (h hull) traceBlocked(node int, start, end Vec3f, depth int) (bool, error) {
+	if depth > len(h.bsp.Nodes)+len(h.bsp.ClipNodes) {
+		return false, errors.New("BSP tree contains a loop")
+	}
+
+	if node < 0 {
+		contents, err := h.contents(node)
+		if err != nil {
+			return false, err
+		}
+
+		return contents == ContentsSolid, nil
+	}
+
+	plane, children, err := h.node(node)
+	if err != nil {
+		return false, err
+	}
+
+	var (
+		startDist = start.Dot(plane.Normal) - plane.Dist
+		endDist   = end.Dot(plane.Normal) - plane.Dist
+	)
+	switch {
+	case startDist >= 0 && endDist >= 0:
+		return h.traceBlocked(children[0], start, end, depth+1)
+	case startDist < 0 && endDist < 0:
+		return h.traceBlocked(children[1], start, end, depth+1)
+	}
+
+	// The line crosses the plane, check both halves starting from the start
+	// side.
+	var (
+		mid  = start.Add(end.Sub(start).Scale(startDist / (startDist - endDist)))
+		side = 0
+	)
+	if startDist < 0 {
+		side = 1
+	}
+
+	blocked, err := h.traceBlocked(children[side], start, mid, depth+1)
+	if err != nil || blocked {
+		return blocked, err
+	}
+
+	return h.traceBlocked(children[1-side], mid, end, depth+1)
+}
@@ -0,0 +1,65 @@
+package bsp_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/L-P/goldutil/goldsrc/bsp"
+	"github.com/L-P/goldutil/internal/bsptest"
+)
+
+func TestPointContents(t *testing.T) {
+	b := bsptest.NewRoom(t)
+
+	leaf, err := b.PointLeaf(bsp.Vec3f{X: 128, Y: 128, Z: 128})
+	require.NoError(t, err)
+	require.Equal(t, 1, leaf)
+
+	leaf, err = b.PointLeaf(bsp.Vec3f{X: -8, Y: 128, Z: 128})
+	require.NoError(t, err)
+	require.Equal(t, 0, leaf)
+
+	for _, c := range []struct {
+		hull     int
+		point    bsp.Vec3f
+		expected bsp.Contents
+	}{
+		{0, bsp.Vec3f{X: 128, Y: 128, Z: 1}, bsp.ContentsEmpty},
+		{0, bsp.Vec3f{X: 128, Y: 128, Z: 257}, bsp.ContentsSolid},
+		{1, bsp.Vec3f{X: 128, Y: 128, Z: 36}, bsp.ContentsEmpty}, // standing on the floor
+		{1, bsp.Vec3f{X: 128, Y: 128, Z: 35}, bsp.ContentsSolid},
+		{1, bsp.Vec3f{X: 8, Y: 128, Z: 128}, bsp.ContentsSolid},
+		{2, bsp.Vec3f{X: 32, Y: 32, Z: 32}, bsp.ContentsEmpty},
+		{2, bsp.Vec3f{X: 31, Y: 32, Z: 32}, bsp.ContentsSolid},
+		{3, bsp.Vec3f{X: 128, Y: 128, Z: 18}, bsp.ContentsEmpty}, // crouching
+		{3, bsp.Vec3f{X: 128, Y: 128, Z: 239}, bsp.ContentsSolid},
+	} {
+		actual, err := b.PointContents(c.hull, c.point)
+		require.NoError(t, err)
+		require.Equal(t, c.expected, actual, "hull %d at %s", c.hull, c.point)
+	}
+
+	_, err = b.PointContents(bsp.MaxMapHulls, bsp.Vec3f{})
+	require.Error(t, err)
+}
+
+func TestTraceBlocked(t *testing.T) {
+	b := bsptest.NewRoom(t)
+
+	for _, c := range []struct {
+		hull       int
+		start, end bsp.Vec3f
+		expected   bool
+	}{
+		{0, bsp.Vec3f{X: 1, Y: 1, Z: 1}, bsp.Vec3f{X: 255, Y: 255, Z: 255}, false},
+		{0, bsp.Vec3f{X: 128, Y: 128, Z: 128}, bsp.Vec3f{X: 300, Y: 128, Z: 128}, true},
+		{0, bsp.Vec3f{X: -10, Y: 128, Z: 128}, bsp.Vec3f{X: 128, Y: 128, Z: 128}, true},
+		{1, bsp.Vec3f{X: 128, Y: 128, Z: 100}, bsp.Vec3f{X: 128, Y: 128, Z: 40}, false},
+		{1, bsp.Vec3f{X: 128, Y: 128, Z: 100}, bsp.Vec3f{X: 128, Y: 128, Z: 20}, true},
+	} {
+		actual, err := b.TraceBlocked(c.hull, c.start, c.end)
+		require.NoError(t, err)
+		require.Equal(t, c.expected, actual, "hull %d from %s to %s", c.hull, c.start, c.end)
+	}
+}
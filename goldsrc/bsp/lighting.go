@@ -0,0 +1,128 @@
+package bsp
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+)
+
+// Changes applied to each lightmap channel, in order: Scale, Gamma, then
+// clamping to [Min;Max].
+type LightingAdjustment struct {
+	Scale    float64 // 1 keeps the brightness
+	Gamma    float64 // 1 keeps the brightness, above 1 brightens dark areas
+	Min, Max uint8
+}
+
+// Returns an adjustment that keeps lightmaps as they are.
+func NewLightingAdjustment() LightingAdjustment {
+	return LightingAdjustment{Scale: 1, Gamma: 1, Max: 0xFF}
+}
+
+// Selects the lightmaps to adjust, empty lists select everything.
+type LightingFilter struct {
+	Styles   []uint8
+	Textures []string // case-insensitive
+	Models   []int
+}
+
+// Returns the new value of each possible channel value.
+func (adj LightingAdjustment) table() [256]uint8 {
+	var ret [256]uint8
+	for i := range ret {
+		v := float64(i) * adj.Scale
+		if adj.Gamma != 1 {
+			v = 255 * math.Pow(max(0, v)/255, 1/adj.Gamma)
+		}
+
+		ret[i] = uint8(min(float64(adj.Max), max(float64(adj.Min), math.Round(v))))
+	}
+
+	return ret
+}
+
+// Adjusts the lightmaps matching the filter in place, returns the number of
+// adjusted lightmaps.
+func (bsp *BSP) AdjustLighting(adj LightingAdjustment, filter LightingFilter) (int, error) {
+	var (
+		table    = adj.table()
+		channels = bsp.lightingChannels()
+		adjusted = map[int32]bool{} // by offset, in case faces share lightmaps
+		count    int
+	)
+
+	for i, face := range bsp.Faces {
+		ok, err := bsp.matchesLightingFilter(i, filter)
+		if err != nil {
+			return 0, err
+		}
+		if !ok || face.LightOffset < 0 {
+			continue
+		}
+
+		ext, err := bsp.FaceLightmapExtents(i)
+		if err != nil {
+			return 0, err
+		}
+
+		size := int32(ext.Width * ext.Height * channels)
+		for slot, style := range face.Styles {
+			if style == NoLightStyle {
+				break
+			}
+
+			offset := face.LightOffset + int32(slot)*size
+			if adjusted[offset] || (len(filter.Styles) > 0 && !slices.Contains(filter.Styles, style)) {
+				continue
+			}
+			if int(offset+size) > len(bsp.Lighting) {
+				return 0, fmt.Errorf("face %d: lightmap style %d is out of the lighting lump bounds", i, style)
+			}
+
+			for j := offset; j < offset+size; j++ {
+				bsp.Lighting[j] = table[bsp.Lighting[j]]
+			}
+			adjusted[offset] = true
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (bsp *BSP) matchesLightingFilter(face int, filter LightingFilter) (bool, error) {
+	texInfo, err := bsp.faceTexInfo(face)
+	if err != nil {
+		return false, err
+	}
+	if texInfo.Flags&TexInfoFlagSpecial != 0 {
+		return false, nil
+	}
+
+	if len(filter.Textures) > 0 {
+		tex, err := bsp.FaceTexture(face)
+		if err != nil {
+			return false, err
+		}
+
+		if !slices.ContainsFunc(filter.Textures, func(v string) bool {
+			return strings.EqualFold(v, tex.Name.String())
+		}) {
+			return false, nil
+		}
+	}
+
+	if len(filter.Models) > 0 {
+		return slices.ContainsFunc(filter.Models, func(i int) bool {
+			if i < 0 || i >= len(bsp.Models) {
+				return false
+			}
+			model := bsp.Models[i]
+
+			return int32(face) >= model.FirstFace && int32(face) < model.FirstFace+model.NumFaces
+		}), nil
+	}
+
+	return true, nil
+}
@@ -0,0 +1,47 @@
+package bsp_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/L-P/goldutil/goldsrc/bsp"
+	"github.com/L-P/goldutil/internal/bsptest"
+)
+
+func TestAdjustLighting(t *testing.T) {
+	b := bsptest.NewRoom(t)
+	original := b.Lighting[b.Faces[5].LightOffset]
+	require.Equal(t, uint8(200), original)
+
+	noop, err := b.AdjustLighting(bsp.NewLightingAdjustment(), bsp.LightingFilter{})
+	require.NoError(t, err)
+	require.Equal(t, 6, noop)
+	require.Equal(t, bsptest.NewRoom(t).Lighting, b.Lighting)
+
+	// Only the walls.
+	adj := bsp.NewLightingAdjustment()
+	adj.Scale = 2
+	adj.Min = 10
+	n, err := b.AdjustLighting(adj, bsp.LightingFilter{Textures: []string{"WALL"}})
+	require.NoError(t, err)
+	require.Equal(t, 4, n)
+
+	for face, expected := range []uint8{10, 80, 160, 240, 160, 200} {
+		require.Equal(t, expected, b.Lighting[b.Faces[face].LightOffset], "face %d", face)
+	}
+
+	// Gamma then clamp.
+	adj = bsp.NewLightingAdjustment()
+	adj.Gamma = 2
+	adj.Max = 220
+	n, err = b.AdjustLighting(adj, bsp.LightingFilter{Models: []int{0}, Styles: []uint8{0}})
+	require.NoError(t, err)
+	require.Equal(t, 6, n)
+	require.Equal(t, uint8(220), b.Lighting[b.Faces[5].LightOffset])
+	require.Equal(t, uint8(50), b.Lighting[b.Faces[0].LightOffset]) // 255*sqrt(10/255)
+
+	n, err = b.AdjustLighting(adj, bsp.LightingFilter{Styles: []uint8{1}})
+	require.NoError(t, err)
+	require.Zero(t, n)
+}
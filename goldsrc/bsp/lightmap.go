@@ -0,0 +1,269 @@
+package bsp
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"slices"
+)
+
+// Size of a luxel in texture space, lightmaps are sampled every 16 texels.
+const LightmapScale = 16
+
+// Light style value marking the end of a face styles list.
+const NoLightStyle = 255
+
+// Largest size of a surface in texels, the engine refuses to load maps with
+// larger surfaces that are not special ("Bad surface extents").
+const MaxSurfaceExtent = 256
+
+// Position and size of a face lightmap in texture space, in luxels.
+type LightmapExtents struct {
+	Mins          [2]int // texture coordinates of the first luxel divided by LightmapScale
+	Width, Height int
+}
+
+// Returns whether the surface is small enough for the engine to load it.
+func (ext LightmapExtents) IsValid() bool {
+	return (ext.Width-1)*LightmapScale <= MaxSurfaceExtent &&
+		(ext.Height-1)*LightmapScale <= MaxSurfaceExtent
+}
+
+// Returns the coordinates of a texture space point in a lightmap image.
+func (ext LightmapExtents) Luxel(s, t float32) (float32, float32) {
+	// Luxels are sampled on their center.
+	return s/LightmapScale - float32(ext.Mins[0]) + 0.5,
+		t/LightmapScale - float32(ext.Mins[1]) + 0.5
+}
+
+// Returns the extents of the lightmap of a face, computed the same way the
+// engine and hlrad do.
+func (bsp *BSP) FaceLightmapExtents(index int) (LightmapExtents, error) {
+	texInfo, err := bsp.faceTexInfo(index)
+	if err != nil {
+		return LightmapExtents{}, err
+	}
+
+	return bsp.faceLightmapExtents(index, texInfo)
+}
+
+// Returns the extents of the lightmap of a face if it used texInfo.
+func (bsp *BSP) faceLightmapExtents(index int, texInfo TexInfo) (LightmapExtents, error) {
+	points, err := bsp.FaceVertices(index)
+	if err != nil {
+		return LightmapExtents{}, err
+	}
+
+	var (
+		mins = [2]float64{math.MaxFloat64, math.MaxFloat64}
+		maxs = [2]float64{-math.MaxFloat64, -math.MaxFloat64}
+	)
+	for _, point := range points {
+		// Computed in double precision like hlrad does.
+		for i, axis := range [2]TexAxis{texInfo.S, texInfo.T} {
+			v := float64(point.X)*float64(axis.Vec.X) +
+				float64(point.Y)*float64(axis.Vec.Y) +
+				float64(point.Z)*float64(axis.Vec.Z) +
+				float64(axis.Offset)
+			mins[i] = min(mins[i], v)
+			maxs[i] = max(maxs[i], v)
+		}
+	}
+
+	var (
+		ret  LightmapExtents
+		size [2]int
+	)
+	for i := range 2 {
+		ret.Mins[i] = int(math.Floor(mins[i] / LightmapScale))
+		size[i] = int(math.Ceil(maxs[i]/LightmapScale)) - ret.Mins[i] + 1
+	}
+	ret.Width, ret.Height = size[0], size[1]
+
+	return ret, nil
+}
+
+// A single light style of a face lightmap.
+type Lightmap struct {
+	Face  int
+	Style uint8
+	Image *image.RGBA
+}
+
+// Returns the lightmaps of a face, one per light style. Faces with special
+// textures (sky, liquids) and faces that were not lit have no lightmaps.
+func (bsp *BSP) FaceLightmaps(index int) ([]Lightmap, error) {
+	texInfo, err := bsp.faceTexInfo(index)
+	if err != nil {
+		return nil, err
+	}
+
+	face := bsp.Faces[index]
+	if texInfo.Flags&TexInfoFlagSpecial != 0 || face.LightOffset < 0 {
+		return nil, nil
+	}
+
+	ext, err := bsp.FaceLightmapExtents(index)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		channels = bsp.lightingChannels()
+		size     = ext.Width * ext.Height * channels
+		offset   = int(face.LightOffset)
+		ret      []Lightmap
+	)
+	for _, style := range face.Styles {
+		if style == NoLightStyle {
+			break
+		}
+
+		if offset+size > len(bsp.Lighting) {
+			return nil, fmt.Errorf("face %d: lightmap style %d is out of the lighting lump bounds", index, style)
+		}
+
+		img := image.NewRGBA(image.Rect(0, 0, ext.Width, ext.Height))
+		for i := range ext.Width * ext.Height {
+			if channels == 1 {
+				gray := bsp.Lighting[offset+i]
+				img.Pix[i*4], img.Pix[i*4+1], img.Pix[i*4+2] = gray, gray, gray
+			} else {
+				copy(img.Pix[i*4:], bsp.Lighting[offset+i*3:offset+i*3+3])
+			}
+			img.Pix[i*4+3] = 0xFF
+		}
+
+		ret = append(ret, Lightmap{Face: index, Style: style, Image: img})
+		offset += size
+	}
+
+	return ret, nil
+}
+
+// Returns the number of bytes per luxel in the lighting lump.
+func (bsp *BSP) lightingChannels() int {
+	if bsp.Variant == VariantQuake {
+		return 1 // grayscale
+	}
+
+	return 3
+}
+
+// Returns the lightmaps of all faces.
+func (bsp *BSP) Lightmaps() ([]Lightmap, error) {
+	var ret []Lightmap
+	for i := range bsp.Faces {
+		lightmaps, err := bsp.FaceLightmaps(i)
+		if err != nil {
+			return nil, err
+		}
+
+		ret = append(ret, lightmaps...)
+	}
+
+	return ret, nil
+}
+
+// LightmapAtlas packs lightmaps in a single image.
+type LightmapAtlas struct {
+	Image   *image.RGBA
+	Entries []LightmapAtlasEntry
+
+	index map[lightmapKey]int
+}
+
+type lightmapKey struct {
+	face  int
+	style uint8
+}
+
+type LightmapAtlasEntry struct {
+	Face  int
+	Style uint8
+	Rect  image.Rectangle
+}
+
+// Space around each lightmap in the atlas, filled with its edge luxels to
+// avoid bleeding when filtering.
+const lightmapAtlasPadding = 1
+
+// Packs the given lightmaps using rows of decreasing height.
+func NewLightmapAtlas(lightmaps []Lightmap) *LightmapAtlas {
+	var (
+		order = make([]int, len(lightmaps))
+		area  int
+		width = 1
+	)
+	for i, lm := range lightmaps {
+		order[i] = i
+		size := lm.Image.Rect.Size().Add(image.Pt(2*lightmapAtlasPadding, 2*lightmapAtlasPadding))
+		area += size.X * size.Y
+		width = max(width, size.X)
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return lightmaps[b].Image.Rect.Dy() - lightmaps[a].Image.Rect.Dy()
+	})
+
+	// Double the width until the atlas is roughly square.
+	for width*width < area {
+		width *= 2
+	}
+
+	var (
+		ret = LightmapAtlas{
+			Entries: make([]LightmapAtlasEntry, len(lightmaps)),
+			index:   make(map[lightmapKey]int, len(lightmaps)),
+		}
+		cursor  image.Point
+		rowSize int
+	)
+	for _, i := range order {
+		size := lightmaps[i].Image.Rect.Size().Add(image.Pt(2*lightmapAtlasPadding, 2*lightmapAtlasPadding))
+		if cursor.X+size.X > width {
+			cursor = image.Pt(0, cursor.Y+rowSize)
+			rowSize = 0
+		}
+
+		pos := cursor.Add(image.Pt(lightmapAtlasPadding, lightmapAtlasPadding))
+		ret.Entries[i] = LightmapAtlasEntry{
+			Face:  lightmaps[i].Face,
+			Style: lightmaps[i].Style,
+			Rect:  image.Rectangle{Min: pos, Max: pos.Add(lightmaps[i].Image.Rect.Size())},
+		}
+		ret.index[lightmapKey{lightmaps[i].Face, lightmaps[i].Style}] = i
+
+		cursor.X += size.X
+		rowSize = max(rowSize, size.Y)
+	}
+
+	ret.Image = image.NewRGBA(image.Rect(0, 0, width, cursor.Y+rowSize))
+	for i, entry := range ret.Entries {
+		ret.blit(lightmaps[i].Image, entry.Rect)
+	}
+
+	return &ret
+}
+
+// Copies the image at the given position and extends its edges into the
+// padding.
+func (atlas *LightmapAtlas) blit(src *image.RGBA, rect image.Rectangle) {
+	padded := rect.Inset(-lightmapAtlasPadding)
+	for y := padded.Min.Y; y < padded.Max.Y; y++ {
+		for x := padded.Min.X; x < padded.Max.X; x++ {
+			sx := min(max(x-rect.Min.X, 0), rect.Dx()-1)
+			sy := min(max(y-rect.Min.Y, 0), rect.Dy()-1)
+			atlas.Image.SetRGBA(x, y, src.RGBAAt(sx, sy))
+		}
+	}
+}
+
+// Returns the position of a face lightmap in the atlas.
+func (atlas *LightmapAtlas) Rect(face int, style uint8) (image.Rectangle, bool) {
+	i, ok := atlas.index[lightmapKey{face, style}]
+	if !ok {
+		return image.Rectangle{}, false
+	}
+
+	return atlas.Entries[i].Rect, true
+}
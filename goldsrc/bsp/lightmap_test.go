@@ -0,0 +1,64 @@
+package bsp_test
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/L-P/goldutil/goldsrc/bsp"
+	"github.com/L-P/goldutil/internal/bsptest"
+)
+
+func TestFaceLightmaps(t *testing.T) {
+	b := bsptest.NewRoom(t)
+
+	ext, err := b.FaceLightmapExtents(1)
+	require.NoError(t, err)
+	require.Equal(t, bsp.LightmapExtents{Mins: [2]int{0, -16}, Width: 17, Height: 17}, ext)
+
+	x, y := ext.Luxel(0, 0)
+	require.Equal(t, []float32{0.5, 16.5}, []float32{x, y})
+
+	lightmaps, err := b.FaceLightmaps(1)
+	require.NoError(t, err)
+	require.Len(t, lightmaps, 1)
+	require.Equal(t, 1, lightmaps[0].Face)
+	require.Equal(t, uint8(0), lightmaps[0].Style)
+	require.Equal(t, image.Rect(0, 0, 17, 17), lightmaps[0].Image.Rect)
+	require.Equal(t, color.RGBA{40, 40, 40, 0xFF}, lightmaps[0].Image.RGBAAt(16, 16))
+
+	b.Lighting = b.Lighting[:len(b.Lighting)-1]
+	_, err = b.FaceLightmaps(5)
+	require.ErrorContains(t, err, "out of the lighting lump bounds")
+}
+
+func TestLightmapAtlas(t *testing.T) {
+	lightmaps, err := bsptest.NewRoom(t).Lightmaps()
+	require.NoError(t, err)
+	require.Len(t, lightmaps, 6)
+
+	atlas := bsp.NewLightmapAtlas(lightmaps)
+	require.Len(t, atlas.Entries, len(lightmaps))
+
+	for i, lm := range lightmaps {
+		rect, ok := atlas.Rect(lm.Face, lm.Style)
+		require.True(t, ok)
+		require.Equal(t, atlas.Entries[i].Rect, rect)
+		require.True(t, rect.In(atlas.Image.Rect))
+
+		for _, other := range atlas.Entries[i+1:] {
+			require.False(t, rect.Inset(-1).Overlaps(other.Rect), "lightmaps are padded")
+		}
+
+		for y := range rect.Dy() {
+			for x := range rect.Dx() {
+				require.Equal(t, lm.Image.RGBAAt(x, y), atlas.Image.RGBAAt(rect.Min.X+x, rect.Min.Y+y))
+			}
+		}
+	}
+
+	_, ok := atlas.Rect(0, 1)
+	require.False(t, ok)
+}
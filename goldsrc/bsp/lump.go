@@ -1,6 +1,7 @@
 package bsp
 
 import (
+	"encoding/binary"
 	"fmt"
 	"io"
 )
@@ -54,7 +55,7 @@ func (t LumpType) EntrySize() int {
 	case LumpTypeClipNodes:
 		return 8
 	case LumpTypeEdges:
-		return 2
+		return 4
 	case LumpTypeEntities:
 		return 1
 	case LumpTypeFaces:
@@ -167,6 +168,24 @@ func init() {
 	if LumpIndexSize != 15 {
 		panic("LumpIndexSize != 15")
 	}
+
+	for typ, v := range map[LumpType]any{
+		LumpTypePlanes:       Plane{},
+		LumpTypeVertices:     Vec3f{},
+		LumpTypeNodes:        Node{},
+		LumpTypeTexInfo:      TexInfo{},
+		LumpTypeFaces:        Face{},
+		LumpTypeClipNodes:    ClipNode{},
+		LumpTypeLeaves:       Leaf{},
+		LumpTypeMarkSurfaces: uint16(0),
+		LumpTypeEdges:        Edge{},
+		LumpTypeSurfEdges:    int32(0),
+		LumpTypeModels:       Model{},
+	} {
+		if actual := binary.Size(v); actual != typ.EntrySize() {
+			panic(fmt.Errorf("invalid size for %T: got %d expected %d", v, actual, typ.EntrySize()))
+		}
+	}
 }
 
 type LumpIndexEntry struct {
@@ -205,3 +224,31 @@ func (lump *RawLump) Write(w io.WriteSeeker) (int, error) {
 func (lump *RawLump) Validate() error {
 	return nil
 }
+
+// Reads a lump made of fixed-size binary records.
+func loadArray[T any](r io.ReadSeeker, entry LumpIndexEntry, dst *[]T) error {
+	var size = binary.Size(*new(T))
+	if entry.Length < 0 || int(entry.Length)%size != 0 {
+		return fmt.Errorf("lump length %d is not a multiple of entry size %d", entry.Length, size)
+	}
+
+	if _, err := r.Seek(int64(entry.Offset), io.SeekStart); err != nil {
+		return fmt.Errorf("unable to seek to %d: %w", entry.Offset, err)
+	}
+
+	*dst = make([]T, int(entry.Length)/size)
+	if err := binary.Read(r, binary.LittleEndian, *dst); err != nil {
+		return fmt.Errorf("unable to read %d entries: %w", len(*dst), err)
+	}
+
+	return nil
+}
+
+// Writes a lump made of fixed-size binary records.
+func writeArray[T any](w io.Writer, src []T) (int, error) {
+	if err := binary.Write(w, binary.LittleEndian, src); err != nil {
+		return 0, fmt.Errorf("unable to write %d entries: %w", len(src), err)
+	}
+
+	return binary.Size(src), nil
+}
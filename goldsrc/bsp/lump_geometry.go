@@ -0,0 +1,233 @@
+package bsp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Maximum number of light styles a single face can be lit by.
+const MaxLightmaps = 4
+
+// Number of collision hulls, hull 0 is the point hull used for rendering.
+const MaxMapHulls = 4
+
+type PlaneType int32
+
+const (
+	PlaneTypeX PlaneType = iota // axial, normal is (±1, 0, 0)
+	PlaneTypeY
+	PlaneTypeZ
+	PlaneTypeAnyX // non-axial, snapped to the nearest axis
+	PlaneTypeAnyY
+	PlaneTypeAnyZ
+)
+
+// Binary-accurate, dplane_t.
+type Plane struct {
+	Normal Vec3f
+	Dist   float32
+	Type   PlaneType
+}
+
+type PlaneLump []Plane
+
+func (lump *PlaneLump) Load(r io.ReadSeeker, entry LumpIndexEntry) error {
+	return loadArray(r, entry, (*[]Plane)(lump))
+}
+
+func (lump *PlaneLump) Write(w io.WriteSeeker) (int, error) {
+	return writeArray(w, *lump)
+}
+
+func (lump *PlaneLump) Validate() error {
+	var errs []error
+	for i, v := range *lump {
+		if v.Type < PlaneTypeX || v.Type > PlaneTypeAnyZ {
+			errs = append(errs, fmt.Errorf("plane %d: invalid type: %d", i, v.Type))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (lump *PlaneLump) String() string {
+	return fmt.Sprintf(" %d planes\n", len(*lump))
+}
+
+type VertexLump []Vec3f
+
+func (lump *VertexLump) Load(r io.ReadSeeker, entry LumpIndexEntry) error {
+	return loadArray(r, entry, (*[]Vec3f)(lump))
+}
+
+func (lump *VertexLump) Write(w io.WriteSeeker) (int, error) {
+	return writeArray(w, *lump)
+}
+
+func (lump *VertexLump) Validate() error {
+	return nil
+}
+
+func (lump *VertexLump) String() string {
+	return fmt.Sprintf(" %d vertices\n", len(*lump))
+}
+
+// Binary-accurate, dedge_t. Indexes in VertexLump.
+type Edge [2]uint16
+
+type EdgeLump []Edge
+
+func (lump *EdgeLump) Load(r io.ReadSeeker, entry LumpIndexEntry) error {
+	return loadArray(r, entry, (*[]Edge)(lump))
+}
+
+func (lump *EdgeLump) Write(w io.WriteSeeker) (int, error) {
+	return writeArray(w, *lump)
+}
+
+func (lump *EdgeLump) Validate() error {
+	return nil
+}
+
+func (lump *EdgeLump) String() string {
+	return fmt.Sprintf(" %d edges\n", len(*lump))
+}
+
+// Indexes in EdgeLump, a negative index means the edge is walked backwards
+// (from its second vertex to its first).
+type SurfEdgeLump []int32
+
+func (lump *SurfEdgeLump) Load(r io.ReadSeeker, entry LumpIndexEntry) error {
+	return loadArray(r, entry, (*[]int32)(lump))
+}
+
+func (lump *SurfEdgeLump) Write(w io.WriteSeeker) (int, error) {
+	return writeArray(w, *lump)
+}
+
+func (lump *SurfEdgeLump) Validate() error {
+	return nil
+}
+
+func (lump *SurfEdgeLump) String() string {
+	return fmt.Sprintf(" %d surfedges\n", len(*lump))
+}
+
+// Binary-accurate, dface_t.
+type Face struct {
+	Plane     uint16 // index in PlaneLump
+	Side      int16  // 1 if the face normal is the opposite of the plane's
+	FirstEdge int32  // index in SurfEdgeLump
+	NumEdges  int16
+	TexInfo   int16 // index in TexInfoLump
+
+	Styles      [MaxLightmaps]uint8 // light styles, 255 means none
+	LightOffset int32               // offset in the Lighting lump, -1 if unlit
+}
+
+type FaceLump []Face
+
+func (lump *FaceLump) Load(r io.ReadSeeker, entry LumpIndexEntry) error {
+	return loadArray(r, entry, (*[]Face)(lump))
+}
+
+func (lump *FaceLump) Write(w io.WriteSeeker) (int, error) {
+	return writeArray(w, *lump)
+}
+
+func (lump *FaceLump) Validate() error {
+	var errs []error
+	for i, v := range *lump {
+		if v.Side != 0 && v.Side != 1 {
+			errs = append(errs, fmt.Errorf("face %d: invalid side: %d", i, v.Side))
+		}
+		if v.NumEdges < 3 {
+			errs = append(errs, fmt.Errorf("face %d: not enough edges: %d", i, v.NumEdges))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (lump *FaceLump) String() string {
+	return fmt.Sprintf(" %d faces\n", len(*lump))
+}
+
+// Texture is not lit and not subdivided (sky, liquids).
+const TexInfoFlagSpecial = 1
+
+// A texture projection axis, the texel coordinate of a point is
+// dot(point, Vec) + Offset.
+type TexAxis struct {
+	Vec    Vec3f
+	Offset float32
+}
+
+// Binary-accurate, texinfo_t.
+type TexInfo struct {
+	S, T   TexAxis
+	MIPTex int32 // index in TextureLump.Textures
+	Flags  int32
+}
+
+type TexInfoLump []TexInfo
+
+func (lump *TexInfoLump) Load(r io.ReadSeeker, entry LumpIndexEntry) error {
+	return loadArray(r, entry, (*[]TexInfo)(lump))
+}
+
+func (lump *TexInfoLump) Write(w io.WriteSeeker) (int, error) {
+	return writeArray(w, *lump)
+}
+
+func (lump *TexInfoLump) Validate() error {
+	var errs []error
+	for i, v := range *lump {
+		if v.MIPTex < 0 {
+			errs = append(errs, fmt.Errorf("texinfo %d: negative texture index: %d", i, v.MIPTex))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (lump *TexInfoLump) String() string {
+	return fmt.Sprintf(" %d texinfos\n", len(*lump))
+}
+
+// Binary-accurate, dmodel_t. Model 0 is the world, others are brush entities
+// referenced as "*N" in the entity lump.
+type Model struct {
+	Mins, Maxs Vec3f
+	Origin     Vec3f
+	HeadNodes  [MaxMapHulls]int32 // hull 0 in NodeLump, others in ClipNodeLump
+	VisLeafs   int32              // not counting the shared solid leaf 0
+	FirstFace  int32              // index in FaceLump
+	NumFaces   int32
+}
+
+type ModelLump []Model
+
+func (lump *ModelLump) Load(r io.ReadSeeker, entry LumpIndexEntry) error {
+	return loadArray(r, entry, (*[]Model)(lump))
+}
+
+func (lump *ModelLump) Write(w io.WriteSeeker) (int, error) {
+	return writeArray(w, *lump)
+}
+
+func (lump *ModelLump) Validate() error {
+	var errs []error
+	for i, v := range *lump {
+		if v.FirstFace < 0 || v.NumFaces < 0 {
+			errs = append(errs, fmt.Errorf("model %d: negative face range: %d+%d", i, v.FirstFace, v.NumFaces))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (lump *ModelLump) String() string {
+	return fmt.Sprintf(" %d models\n", len(*lump))
+}
@@ -2,6 +2,7 @@ package bsp
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"encoding/binary"
 	"errors"
 	"fmt"
@@ -18,6 +19,13 @@ type TextureLump struct {
 	// Type signs are inconsistents in the documentation (VDN).
 	Offsets  []int32 // len = Count, offset from TextureLump start
 	Textures []wad.MIPTexture
+
+	paletteless bool // Quake textures, set by BSP.Load and BSP.Write
+
+	// Lump as last read or written and the digest of its textures at the
+	// time, unchanged textures are written back verbatim.
+	raw    []byte
+	digest [sha256.Size]byte
 }
 
 func (lump *TextureLump) Load(r io.ReadSeeker, entry LumpIndexEntry) error {
@@ -37,6 +45,20 @@ func (lump *TextureLump) Load(r io.ReadSeeker, entry LumpIndexEntry) error {
 		return fmt.Errorf("unable to parse and load TextureLump textures: %w", err)
 	}
 
+	if _, err := r.Seek(int64(entry.Offset), io.SeekStart); err != nil {
+		return fmt.Errorf("unable to seek to TextureLump start: %w", err)
+	}
+	lump.raw = make([]byte, entry.Length)
+	if _, err := io.ReadFull(r, lump.raw); err != nil {
+		return fmt.Errorf("unable to read TextureLump: %w", err)
+	}
+
+	digest, err := lump.texturesDigest()
+	if err != nil {
+		return err
+	}
+	lump.digest = digest
+
 	return nil
 }
 
@@ -62,8 +84,13 @@ func (lump *TextureLump) loadHeader(r io.ReadSeeker, entry LumpIndexEntry) error
 
 func (lump *TextureLump) loadTextures(r io.ReadSeeker, entry LumpIndexEntry) error {
 	lump.Textures = make([]wad.MIPTexture, lump.Count)
+	read := (*wad.MIPTexture).Read
+	if lump.paletteless {
+		read = (*wad.MIPTexture).ReadPaletteless
+	}
+
 	for i, offset := range lump.Offsets {
-		if err := lump.Textures[i].Read(r, entry.Offset+offset); err != nil {
+		if err := read(&lump.Textures[i], r, entry.Offset+offset); err != nil {
 			return fmt.Errorf("unable to read texture #%d: %w", i, err)
 		}
 	}
@@ -115,40 +142,128 @@ func (lump *TextureLump) Validate() error {
 	return errors.Join(errs...)
 }
 
+// Writes the lump, Count and Offsets are recomputed to allow adding,
+// removing, and resizing textures. A lump whose textures did not change
+// since it was loaded is written as it was read, keeping the layout of the
+// compiler.
 func (lump *TextureLump) Write(w io.WriteSeeker) (int, error) {
-	var offset int
+	digest, err := lump.texturesDigest()
+	if err != nil {
+		return 0, err
+	}
 
-	if err := binary.Write(w, binary.LittleEndian, lump.Count); err != nil {
-		return offset, fmt.Errorf("unable to write texture count: %w", err)
+	if lump.raw != nil && lump.digest == digest {
+		if _, err := w.Write(lump.raw); err != nil {
+			return 0, fmt.Errorf("unable to write textures: %w", err)
+		}
+
+		return len(lump.raw), nil
+	}
+
+	var buf bytes.Buffer
+	n, err := lump.writeCanonical(&buf)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		return 0, fmt.Errorf("unable to write textures: %w", err)
 	}
-	offset += binary.Size(lump.Count)
 
-	var offsetsOffset = offset // semantic satiety anyone?
+	lump.raw = buf.Bytes()
+	lump.digest, err = lump.texturesDigest()
+	if err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
+
+// Writes the textures one after the other, each followed by its MIP data.
+func (lump *TextureLump) writeCanonical(w io.Writer) (int, error) {
+	lump.Count = uint32(len(lump.Textures))
+	lump.Offsets = make([]int32, len(lump.Textures))
+
+	var offset = int32(binary.Size(lump.Count) + binary.Size(lump.Offsets))
+	for i := range lump.Textures {
+		lump.Offsets[i] = offset
+		offset += lump.textureSize(&lump.Textures[i])
+	}
+
+	if err := binary.Write(w, binary.LittleEndian, lump.Count); err != nil {
+		return 0, fmt.Errorf("unable to write texture count: %w", err)
+	}
 	if err := binary.Write(w, binary.LittleEndian, lump.Offsets); err != nil {
-		return offset, fmt.Errorf("unable to write provisional texture offsets: %w", err)
+		return 0, fmt.Errorf("unable to write texture offsets: %w", err)
 	}
-	offset += binary.Size(lump.Offsets)
 
-	for i, v := range lump.Textures {
-		n, err := v.Write(w)
-		if err != nil {
-			return offset, fmt.Errorf("unable to write texture #%d: %w", i, err)
+	for i := range lump.Textures {
+		if err := lump.writeTexture(w, &lump.Textures[i]); err != nil {
+			return 0, fmt.Errorf("unable to write texture #%d: %w", i, err)
 		}
-		offset += n
 	}
 
-	if offset < offsetsOffset {
-		panic("unreachable, offset < offsetsOffset")
+	return int(offset), nil
+}
+
+// Hashes everything written for the textures, to detect changes.
+func (lump *TextureLump) texturesDigest() ([sha256.Size]byte, error) {
+	var (
+		ret    [sha256.Size]byte
+		h      = sha256.New()
+		fields = []any{lump.paletteless, uint32(len(lump.Textures))}
+	)
+	for _, tex := range lump.Textures {
+		fields = append(fields, tex.MIPTextureHeader)
+		for _, data := range tex.MIPData {
+			fields = append(fields, uint32(len(data)), data)
+		}
+		fields = append(fields, tex.PaletteSize, tex.Palette)
 	}
 
-	if _, err := w.Seek(-int64(offset-offsetsOffset), io.SeekCurrent); err != nil {
-		return offset, fmt.Errorf("unable to seek to start of file to finalize header: %w", err)
+	for _, v := range fields {
+		if err := binary.Write(h, binary.LittleEndian, v); err != nil {
+			return ret, fmt.Errorf("unable to hash textures: %w", err)
+		}
 	}
-	if err := binary.Write(w, binary.LittleEndian, lump.Offsets); err != nil {
-		return offset, fmt.Errorf("unable to write provisional texture offsets: %w", err)
+	h.Sum(ret[:0])
+
+	return ret, nil
+}
+
+// Textures that are not embedded only have their header in the BSP, the
+// engine looks for their data in the WADs.
+func (lump *TextureLump) textureSize(tex *wad.MIPTexture) int32 {
+	if !tex.IsEmbedded() {
+		return wad.MIPTextureHeaderSize
+	}
+
+	if lump.paletteless {
+		return tex.PalettelessSize()
+	}
+
+	return tex.Size()
+}
+
+func (lump *TextureLump) writeTexture(w io.Writer, tex *wad.MIPTexture) error {
+	if !tex.IsEmbedded() {
+		return binary.Write(w, binary.LittleEndian, tex.MIPTextureHeader)
+	}
+
+	// MIP data is always written right after the header, in order.
+	var offset = wad.MIPTextureHeaderSize
+	for i := range tex.MIPData {
+		tex.MIPOffsets[i] = offset
+		offset += int32(len(tex.MIPData[i]))
+	}
+
+	write := tex.Write
+	if lump.paletteless {
+		write = tex.WritePaletteless
 	}
+	_, err := write(w)
 
-	return offset, nil
+	return err
 }
 
 func (lump *TextureLump) String() string {
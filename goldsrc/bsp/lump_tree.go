@@ -0,0 +1,185 @@
+package bsp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Binary-accurate, dnode_t.
+// Positive children are indexes in NodeLump, negative children are leaves
+// encoded as -(leaf+1).
+type Node struct {
+	Plane      int32 // index in PlaneLump
+	Children   [2]int16
+	Mins, Maxs Vec3s
+	FirstFace  uint16 // index in FaceLump
+	NumFaces   uint16
+}
+
+type NodeLump []Node
+
+func (lump *NodeLump) Load(r io.ReadSeeker, entry LumpIndexEntry) error {
+	return loadArray(r, entry, (*[]Node)(lump))
+}
+
+func (lump *NodeLump) Write(w io.WriteSeeker) (int, error) {
+	return writeArray(w, *lump)
+}
+
+func (lump *NodeLump) Validate() error {
+	return nil
+}
+
+func (lump *NodeLump) String() string {
+	return fmt.Sprintf(" %d nodes\n", len(*lump))
+}
+
+// Contents of a leaf or of a clipnode child.
+type Contents int32
+
+const (
+	ContentsEmpty Contents = -1 - iota
+	ContentsSolid
+	ContentsWater
+	ContentsSlime
+	ContentsLava
+	ContentsSky
+	ContentsOrigin // removed by the compiler
+	ContentsClip   // turned into solid in clip hulls by the compiler
+	ContentsCurrent0
+	ContentsCurrent90
+	ContentsCurrent180
+	ContentsCurrent270
+	ContentsCurrentUp
+	ContentsCurrentDown
+	ContentsTranslucent
+)
+
+func (c Contents) String() string {
+	switch c {
+	case ContentsEmpty:
+		return "empty"
+	case ContentsSolid:
+		return "solid"
+	case ContentsWater:
+		return "water"
+	case ContentsSlime:
+		return "slime"
+	case ContentsLava:
+		return "lava"
+	case ContentsSky:
+		return "sky"
+	case ContentsOrigin:
+		return "origin"
+	case ContentsClip:
+		return "clip"
+	case ContentsCurrent0:
+		return "current_0"
+	case ContentsCurrent90:
+		return "current_90"
+	case ContentsCurrent180:
+		return "current_180"
+	case ContentsCurrent270:
+		return "current_270"
+	case ContentsCurrentUp:
+		return "current_up"
+	case ContentsCurrentDown:
+		return "current_down"
+	case ContentsTranslucent:
+		return "translucent"
+	default:
+		return fmt.Sprintf("unknown contents %d", int32(c))
+	}
+}
+
+// Number of ambient sound channels stored in each leaf.
+const NumAmbients = 4
+
+// Binary-accurate, dleaf_t.
+type Leaf struct {
+	Contents  Contents
+	VisOffset int32 // offset in the Visibility lump, -1 if everything is visible
+
+	Mins, Maxs Vec3s
+
+	FirstMarkSurface uint16 // index in MarkSurfaceLump
+	NumMarkSurfaces  uint16
+
+	AmbientLevels [NumAmbients]uint8
+}
+
+// Returns the center of the leaf bounding box.
+func (leaf Leaf) Center() Vec3f {
+	return leaf.Mins.Vec3f().Add(leaf.Maxs.Vec3f()).Scale(0.5)
+}
+
+type LeafLump []Leaf
+
+func (lump *LeafLump) Load(r io.ReadSeeker, entry LumpIndexEntry) error {
+	return loadArray(r, entry, (*[]Leaf)(lump))
+}
+
+func (lump *LeafLump) Write(w io.WriteSeeker) (int, error) {
+	return writeArray(w, *lump)
+}
+
+func (lump *LeafLump) Validate() error {
+	var errs []error
+	for i, v := range *lump {
+		if v.Contents >= 0 {
+			errs = append(errs, fmt.Errorf("leaf %d: invalid contents: %d", i, v.Contents))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (lump *LeafLump) String() string {
+	return fmt.Sprintf(" %d leaves\n", len(*lump))
+}
+
+// Binary-accurate, dclipnode_t.
+// Positive children are indexes in ClipNodeLump, negative children are
+// contents.
+type ClipNode struct {
+	Plane    int32 // index in PlaneLump
+	Children [2]int16
+}
+
+type ClipNodeLump []ClipNode
+
+func (lump *ClipNodeLump) Load(r io.ReadSeeker, entry LumpIndexEntry) error {
+	return loadArray(r, entry, (*[]ClipNode)(lump))
+}
+
+func (lump *ClipNodeLump) Write(w io.WriteSeeker) (int, error) {
+	return writeArray(w, *lump)
+}
+
+func (lump *ClipNodeLump) Validate() error {
+	return nil
+}
+
+func (lump *ClipNodeLump) String() string {
+	return fmt.Sprintf(" %d clipnodes\n", len(*lump))
+}
+
+// Indexes in FaceLump, referenced by leaves.
+type MarkSurfaceLump []uint16
+
+func (lump *MarkSurfaceLump) Load(r io.ReadSeeker, entry LumpIndexEntry) error {
+	return loadArray(r, entry, (*[]uint16)(lump))
+}
+
+func (lump *MarkSurfaceLump) Write(w io.WriteSeeker) (int, error) {
+	return writeArray(w, *lump)
+}
+
+func (lump *MarkSurfaceLump) Validate() error {
+	return nil
+}
+
+func (lump *MarkSurfaceLump) String() string {
+	return fmt.Sprintf(" %d marksurfaces\n", len(*lump))
+}
@@ -0,0 +1,220 @@
+package mesh
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Subset of the glTF 2.0 schema.
+type gltfDocument struct {
+	Asset       gltfAsset        `json:"asset"`
+	Scene       int              `json:"scene"`
+	Scenes      []gltfScene      `json:"scenes"`
+	Nodes       []gltfNode       `json:"nodes"`
+	Meshes      []gltfMesh       `json:"meshes"`
+	Materials   []gltfMaterial   `json:"materials,omitempty"`
+	Textures    []gltfTexture    `json:"textures,omitempty"`
+	Images      []gltfImage      `json:"images,omitempty"`
+	Accessors   []gltfAccessor   `json:"accessors"`
+	BufferViews []gltfBufferView `json:"bufferViews"`
+	Buffers     []gltfBuffer     `json:"buffers"`
+}
+
+type gltfAsset struct {
+	Version   string `json:"version"`
+	Generator string `json:"generator"`
+}
+
+type gltfScene struct {
+	Nodes []int `json:"nodes"`
+}
+
+type gltfNode struct {
+	Name string `json:"name"`
+	Mesh int    `json:"mesh"`
+}
+
+type gltfMesh struct {
+	Name       string          `json:"name"`
+	Primitives []gltfPrimitive `json:"primitives"`
+}
+
+type gltfPrimitive struct {
+	Attributes map[string]int `json:"attributes"`
+	Indices    int            `json:"indices"`
+	Material   int            `json:"material"`
+}
+
+type gltfMaterial struct {
+	Name        string                   `json:"name"`
+	PBR         gltfPBRMetallicRoughness `json:"pbrMetallicRoughness"`
+	AlphaMode   string                   `json:"alphaMode,omitempty"`
+	AlphaCutoff *float32                 `json:"alphaCutoff,omitempty"`
+}
+
+type gltfPBRMetallicRoughness struct {
+	BaseColorTexture *gltfTextureInfo `json:"baseColorTexture,omitempty"`
+	MetallicFactor   float32          `json:"metallicFactor"`
+	RoughnessFactor  float32          `json:"roughnessFactor"`
+}
+
+type gltfTextureInfo struct {
+	Index int `json:"index"`
+}
+
+type gltfTexture struct {
+	Source int `json:"source"`
+}
+
+type gltfImage struct {
+	URI string `json:"uri"`
+}
+
+type gltfAccessor struct {
+	BufferView    int       `json:"bufferView"`
+	ComponentType int       `json:"componentType"`
+	Count         int       `json:"count"`
+	Type          string    `json:"type"`
+	Min           []float32 `json:"min,omitempty"`
+	Max           []float32 `json:"max,omitempty"`
+}
+
+type gltfBufferView struct {
+	Buffer     int `json:"buffer"`
+	ByteOffset int `json:"byteOffset"`
+	ByteLength int `json:"byteLength"`
+	Target     int `json:"target"`
+}
+
+type gltfBuffer struct {
+	ByteLength int    `json:"byteLength"`
+	URI        string `json:"uri"`
+}
+
+const (
+	gltfFloat       = 5126
+	gltfUnsignedInt = 5125
+
+	gltfArrayBuffer        = 34962
+	gltfElementArrayBuffer = 34963
+)
+
+// Writes the scene as a glTF 2.0 JSON file with its geometry embedded.
+// Textures are referenced using their Path.
+func (scene *Scene) WriteGLTF(w io.Writer) error {
+	var (
+		doc = gltfDocument{
+			Asset:  gltfAsset{Version: "2.0", Generator: "goldutil"},
+			Scenes: []gltfScene{{Nodes: []int{}}},
+		}
+		buf bytes.Buffer
+	)
+
+	for _, mat := range scene.Materials {
+		doc.Materials = append(doc.Materials, doc.material(mat))
+	}
+
+	for _, mesh := range scene.Meshes {
+		out := gltfMesh{Name: mesh.Name, Primitives: []gltfPrimitive{}}
+		for _, surface := range mesh.Surfaces {
+			out.Primitives = append(out.Primitives, doc.primitive(&buf, surface))
+		}
+
+		doc.Scenes[0].Nodes = append(doc.Scenes[0].Nodes, len(doc.Nodes))
+		doc.Nodes = append(doc.Nodes, gltfNode{Name: mesh.Name, Mesh: len(doc.Meshes)})
+		doc.Meshes = append(doc.Meshes, out)
+	}
+
+	doc.Buffers = []gltfBuffer{{
+		ByteLength: buf.Len(),
+		URI:        "data:application/octet-stream;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()),
+	}}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("unable to write glTF: %w", err)
+	}
+
+	return nil
+}
+
+func (doc *gltfDocument) material(mat Material) gltfMaterial {
+	ret := gltfMaterial{
+		Name: mat.Name,
+		PBR:  gltfPBRMetallicRoughness{RoughnessFactor: 1},
+	}
+
+	if mat.Path != "" {
+		ret.PBR.BaseColorTexture = &gltfTextureInfo{Index: len(doc.Textures)}
+		doc.Textures = append(doc.Textures, gltfTexture{Source: len(doc.Images)})
+		doc.Images = append(doc.Images, gltfImage{URI: mat.Path})
+
+		if mat.IsTransparent() {
+			cutoff := float32(0.5)
+			ret.AlphaMode = "MASK"
+			ret.AlphaCutoff = &cutoff
+		}
+	}
+
+	return ret
+}
+
+func (doc *gltfDocument) primitive(buf *bytes.Buffer, surface Surface) gltfPrimitive {
+	var (
+		positions = make([]float32, 0, len(surface.Vertices)*3)
+		normals   = make([]float32, 0, len(surface.Vertices)*3)
+		uvs       = make([]float32, 0, len(surface.Vertices)*2)
+		mins      = []float32{math.MaxFloat32, math.MaxFloat32, math.MaxFloat32}
+		maxs      = []float32{-math.MaxFloat32, -math.MaxFloat32, -math.MaxFloat32}
+	)
+	for _, v := range surface.Vertices {
+		pos := []float32{v.Position.X, v.Position.Y, v.Position.Z}
+		for i := range pos {
+			mins[i] = min(mins[i], pos[i])
+			maxs[i] = max(maxs[i], pos[i])
+		}
+
+		positions = append(positions, pos...)
+		normals = append(normals, v.Normal.X, v.Normal.Y, v.Normal.Z)
+		uvs = append(uvs, v.UV[0], v.UV[1])
+	}
+
+	position := doc.accessor(buf, positions, gltfFloat, "VEC3", gltfArrayBuffer)
+	doc.Accessors[position].Min = mins
+	doc.Accessors[position].Max = maxs
+
+	return gltfPrimitive{
+		Attributes: map[string]int{
+			"POSITION":   position,
+			"NORMAL":     doc.accessor(buf, normals, gltfFloat, "VEC3", gltfArrayBuffer),
+			"TEXCOORD_0": doc.accessor(buf, uvs, gltfFloat, "VEC2", gltfArrayBuffer),
+		},
+		Indices:  doc.accessor(buf, surface.Indices, gltfUnsignedInt, "SCALAR", gltfElementArrayBuffer),
+		Material: surface.Material,
+	}
+}
+
+// Appends data to the buffer and returns the index of its new accessor.
+func (doc *gltfDocument) accessor(buf *bytes.Buffer, data any, componentType int, typ string, target int) int {
+	var components = map[string]int{"SCALAR": 1, "VEC2": 2, "VEC3": 3}[typ]
+
+	view := gltfBufferView{ByteOffset: buf.Len(), Target: target}
+	_ = binary.Write(buf, binary.LittleEndian, data) // bytes.Buffer never fails
+	view.ByteLength = buf.Len() - view.ByteOffset
+
+	doc.BufferViews = append(doc.BufferViews, view)
+	doc.Accessors = append(doc.Accessors, gltfAccessor{
+		BufferView:    len(doc.BufferViews) - 1,
+		ComponentType: componentType,
+		Count:         view.ByteLength / 4 / components,
+		Type:          typ,
+	})
+
+	return len(doc.Accessors) - 1
+}
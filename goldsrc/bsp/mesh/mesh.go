@@ -0,0 +1,349 @@
+// Package mesh converts BSP models to triangle meshes that can be written in
+// common interchange formats.
+// Meshes use Y-up right-handed coordinates and counter-clockwise winding.
+package mesh
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/L-P/goldutil/goldsrc/bsp"
+	"github.com/L-P/goldutil/goldsrc/wad"
+)
+
+type Scene struct {
+	Meshes    []Mesh
+	Materials []Material
+}
+
+// Mesh holds the faces of a single BSP model.
+type Mesh struct {
+	Name     string
+	Surfaces []Surface
+}
+
+// Surface holds the triangles of a mesh sharing the same material.
+type Surface struct {
+	Material int // index in Scene.Materials
+	Vertices []Vertex
+	Indices  []uint32
+}
+
+type Vertex struct {
+	Position bsp.Vec3f
+	Normal   bsp.Vec3f
+	UV       [2]float32 // origin at the top left of the texture
+}
+
+type Material struct {
+	Name          string
+	Width, Height int
+	Image         image.Image // nil if the texture could not be found or rendered
+	Path          string      // where the image is stored, set by the caller
+}
+
+// Textures starting with a '{' use palette index 255 as transparency.
+func (mat Material) IsTransparent() bool {
+	return strings.HasPrefix(mat.Name, "{")
+}
+
+type Options struct {
+	// Where to look for textures that are not embedded in the BSP.
+	WADs wad.Collection
+
+	// Multiplier applied to positions, a GoldSrc unit is roughly an inch.
+	Scale float32
+
+	// Textures whose faces are not exported, case-insensitive.
+	SkipTextures []string
+
+	// Only export the world and not brush entities.
+	WorldOnly bool
+
+	// Replace textures with a single lightmap atlas, faces that have no
+	// lightmap are fullbright.
+	Lightmaps bool
+}
+
+type builder struct {
+	bsp       *bsp.BSP
+	opts      Options
+	scene     Scene
+	materials map[int32]int // BSP texture index to material index
+	atlas     *bsp.LightmapAtlas
+}
+
+// Name of the material used by Options.Lightmaps.
+const LightmapMaterial = "lightmap"
+
+// Index of the fullbright luxel in the lightmap atlas.
+const fullbrightFace = -1
+
+func New(b *bsp.BSP, opts Options) (*Scene, error) {
+	if opts.Scale == 0 {
+		opts.Scale = 1
+	}
+
+	names, origins, err := modelEntities(b)
+	if err != nil {
+		return nil, err
+	}
+
+	builder := builder{bsp: b, opts: opts, materials: map[int32]int{}}
+	if opts.Lightmaps {
+		if err := builder.initAtlas(); err != nil {
+			return nil, err
+		}
+	}
+
+	for i, model := range b.Models {
+		if i > 0 && opts.WorldOnly {
+			break
+		}
+
+		mesh := Mesh{Name: names[i]}
+		surfaces := map[int]int{} // material to surface index
+		for face := model.FirstFace; face < model.FirstFace+model.NumFaces; face++ {
+			mat, ok, err := builder.material(int(face))
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+
+			surface, ok := surfaces[mat]
+			if !ok {
+				surface = len(mesh.Surfaces)
+				surfaces[mat] = surface
+				mesh.Surfaces = append(mesh.Surfaces, Surface{Material: mat})
+			}
+
+			if err := builder.addFace(&mesh.Surfaces[surface], int(face), origins[i]); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(mesh.Surfaces) > 0 {
+			builder.scene.Meshes = append(builder.scene.Meshes, mesh)
+		}
+	}
+
+	return &builder.scene, nil
+}
+
+// Packs the first light style of each face in a single material.
+func (builder *builder) initAtlas() error {
+	all, err := builder.bsp.Lightmaps()
+	if err != nil {
+		return fmt.Errorf("unable to read lightmaps: %w", err)
+	}
+
+	fullbright := image.NewRGBA(image.Rect(0, 0, 1, 1))
+	fullbright.SetRGBA(0, 0, color.RGBA{0xFF, 0xFF, 0xFF, 0xFF})
+	lightmaps := []bsp.Lightmap{{Face: fullbrightFace, Image: fullbright}}
+	for _, lm := range all {
+		if lm.Style == builder.bsp.Faces[lm.Face].Styles[0] {
+			lightmaps = append(lightmaps, lm)
+		}
+	}
+
+	builder.atlas = bsp.NewLightmapAtlas(lightmaps)
+	builder.scene.Materials = []Material{{
+		Name:   LightmapMaterial,
+		Width:  builder.atlas.Image.Rect.Dx(),
+		Height: builder.atlas.Image.Rect.Dy(),
+		Image:  builder.atlas.Image,
+	}}
+
+	return nil
+}
+
+// Returns the material index of a face, false if the face is skipped.
+func (builder *builder) material(face int) (int, bool, error) {
+	tex, err := builder.bsp.FaceTexture(face)
+	if err != nil {
+		return 0, false, err
+	}
+
+	if slices.ContainsFunc(builder.opts.SkipTextures, func(v string) bool {
+		return strings.EqualFold(v, tex.Name.String())
+	}) {
+		return 0, false, nil
+	}
+
+	if builder.atlas != nil {
+		return 0, true, nil
+	}
+
+	miptex := builder.bsp.TexInfo[builder.bsp.Faces[face].TexInfo].MIPTex
+	if mat, ok := builder.materials[miptex]; ok {
+		return mat, true, nil
+	}
+
+	mat, err := newMaterial(tex, builder.opts.WADs, builder.bsp.Variant == bsp.VariantQuake)
+	if err != nil {
+		return 0, false, err
+	}
+
+	builder.materials[miptex] = len(builder.scene.Materials)
+	builder.scene.Materials = append(builder.scene.Materials, mat)
+
+	return builder.materials[miptex], true, nil
+}
+
+func newMaterial(tex wad.MIPTexture, wads wad.Collection, paletteless bool) (Material, error) {
+	ret := Material{
+		Name:   tex.Name.String(),
+		Width:  int(tex.Width),
+		Height: int(tex.Height),
+	}
+
+	// Quake textures use the palette of the game, export them untextured.
+	if tex.IsEmbedded() && paletteless {
+		return ret, nil
+	}
+
+	if !tex.IsEmbedded() {
+		var ok bool
+		if tex, ok = wads.GetTexture(ret.Name); !ok {
+			return ret, nil
+		}
+	}
+
+	img, err := tex.Render(true)
+	if err != nil {
+		return Material{}, fmt.Errorf("unable to render texture %s: %w", ret.Name, err)
+	}
+	ret.Image = img
+
+	return ret, nil
+}
+
+func (builder *builder) addFace(surface *Surface, face int, origin bsp.Vec3f) error {
+	points, err := builder.bsp.FaceVertices(face)
+	if err != nil {
+		return err
+	}
+
+	normal, err := builder.bsp.FaceNormal(face)
+	if err != nil {
+		return err
+	}
+
+	uv, err := builder.uvMapper(face)
+	if err != nil {
+		return err
+	}
+
+	first := uint32(len(surface.Vertices))
+	for _, point := range points {
+		surface.Vertices = append(surface.Vertices, Vertex{
+			Position: yUp(point.Add(origin)).Scale(builder.opts.Scale),
+			Normal:   yUp(normal),
+			UV:       uv(point),
+		})
+	}
+
+	// Faces are convex and clockwise, triangulate as a reversed fan.
+	for i := uint32(1); i < uint32(len(points))-1; i++ {
+		surface.Indices = append(surface.Indices, first, first+i+1, first+i)
+	}
+
+	return nil
+}
+
+// Returns a function giving the UV of a face vertex.
+func (builder *builder) uvMapper(face int) (func(bsp.Vec3f) [2]float32, error) {
+	texInfo := builder.bsp.TexInfo[builder.bsp.Faces[face].TexInfo]
+	if builder.atlas == nil {
+		tex, err := builder.bsp.FaceTexture(face)
+		if err != nil {
+			return nil, err
+		}
+
+		return func(point bsp.Vec3f) [2]float32 {
+			s, t := texInfo.TexCoords(point)
+			return [2]float32{s / float32(tex.Width), t / float32(tex.Height)}
+		}, nil
+	}
+
+	var (
+		size     = builder.atlas.Image.Rect.Size()
+		rect, ok = builder.atlas.Rect(face, builder.bsp.Faces[face].Styles[0])
+	)
+	if !ok {
+		rect, _ = builder.atlas.Rect(fullbrightFace, 0)
+		return func(bsp.Vec3f) [2]float32 {
+			return [2]float32{
+				(float32(rect.Min.X) + 0.5) / float32(size.X),
+				(float32(rect.Min.Y) + 0.5) / float32(size.Y),
+			}
+		}, nil
+	}
+
+	ext, err := builder.bsp.FaceLightmapExtents(face)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(point bsp.Vec3f) [2]float32 {
+		x, y := ext.Luxel(texInfo.TexCoords(point))
+		return [2]float32{
+			(float32(rect.Min.X) + x) / float32(size.X),
+			(float32(rect.Min.Y) + y) / float32(size.Y),
+		}
+	}, nil
+}
+
+// GoldSrc is Z-up, X forward, Y left.
+func yUp(v bsp.Vec3f) bsp.Vec3f {
+	return bsp.Vec3f{X: v.X, Y: v.Z, Z: -v.Y}
+}
+
+// Returns the name and origin of each model from the entity using it.
+// Brush entities with an origin brush have their faces centered on the
+// world origin and are moved in place by their "origin" key.
+func modelEntities(b *bsp.BSP) ([]string, []bsp.Vec3f, error) {
+	var (
+		names   = make([]string, len(b.Models))
+		origins = make([]bsp.Vec3f, len(b.Models))
+	)
+	for i := range names {
+		names[i] = "*" + strconv.Itoa(i)
+	}
+	if len(names) > 0 {
+		names[0] = "world"
+	}
+
+	qm, err := b.LoadEntities()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for ent := range qm.Entities() {
+		model, ok := strings.CutPrefix(ent.KVs["model"], "*")
+		if !ok {
+			continue
+		}
+
+		i, err := strconv.Atoi(model)
+		if err != nil || i < 1 || i >= len(b.Models) {
+			continue
+		}
+
+		names[i] += " " + ent.KVs["classname"]
+		if origin, ok := ent.KVs["origin"]; ok {
+			origins[i], err = bsp.ParseVec3f(origin)
+			if err != nil {
+				return nil, nil, fmt.Errorf("unable to read origin of model *%d: %w", i, err)
+			}
+		}
+	}
+
+	return names, origins, nil
+}
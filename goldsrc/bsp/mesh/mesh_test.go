@@ -0,0 +1,141 @@
+package mesh_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/L-P/goldutil/goldsrc/bsp"
+	"github.com/L-P/goldutil/goldsrc/bsp/mesh"
+	"github.com/L-P/goldutil/goldsrc/wad"
+	"github.com/L-P/goldutil/internal/bsptest"
+)
+
+func TestNew(t *testing.T) {
+	external := wad.New()
+	wall, err := wad.NewMIPTexture("WALL", 64, 64)
+	require.NoError(t, err)
+	require.NoError(t, wall.SetData(make([]byte, 64*64)))
+	require.NoError(t, external.AddTexture(wall))
+
+	scene, err := mesh.New(bsptest.NewRoom(t), mesh.Options{WADs: wad.Collection{external}})
+	require.NoError(t, err)
+
+	require.Len(t, scene.Meshes, 1)
+	require.Equal(t, "world", scene.Meshes[0].Name)
+	require.Len(t, scene.Materials, 2)
+	for _, mat := range scene.Materials {
+		require.NotNil(t, mat.Image, mat.Name)
+	}
+
+	// The room is seen from inside, every triangle must face its center.
+	var (
+		center    = bsp.Vec3f{X: 128, Y: 128, Z: -128}
+		triangles int
+	)
+	for _, surface := range scene.Meshes[0].Surfaces {
+		for i := 0; i < len(surface.Indices); i += 3 {
+			var (
+				a = surface.Vertices[surface.Indices[i]]
+				b = surface.Vertices[surface.Indices[i+1]]
+				c = surface.Vertices[surface.Indices[i+2]]
+			)
+			// Counter-clockwise winding.
+			require.Positive(t, b.Position.Sub(a.Position).Cross(c.Position.Sub(a.Position)).Dot(a.Normal))
+			require.Positive(t, center.Sub(a.Position).Dot(a.Normal))
+			triangles++
+		}
+	}
+	require.Equal(t, 12, triangles)
+}
+
+func TestNewSkipTextures(t *testing.T) {
+	scene, err := mesh.New(bsptest.NewRoom(t), mesh.Options{SkipTextures: []string{"WALL"}})
+	require.NoError(t, err)
+
+	require.Len(t, scene.Materials, 1)
+	require.Equal(t, "floor", scene.Materials[0].Name)
+	require.Len(t, scene.Meshes[0].Surfaces[0].Indices, 2*2*3)
+}
+
+func TestNewQuake(t *testing.T) {
+	room := bsptest.NewRoom(t)
+	room.Variant = bsp.VariantQuake
+
+	scene, err := mesh.New(room, mesh.Options{})
+	require.NoError(t, err)
+	require.Len(t, scene.Materials, 2)
+	for _, mat := range scene.Materials {
+		require.Nil(t, mat.Image, mat.Name)
+	}
+}
+
+func TestWriteOBJ(t *testing.T) {
+	scene, err := mesh.New(bsptest.NewRoom(t), mesh.Options{})
+	require.NoError(t, err)
+
+	var obj, mtl bytes.Buffer
+	require.NoError(t, scene.WriteOBJ(&obj, "room.mtl"))
+	require.NoError(t, scene.WriteMTL(&mtl))
+
+	counts := map[string]int{}
+	for line := range strings.Lines(obj.String()) {
+		typ, _, _ := strings.Cut(line, " ")
+		counts[typ]++
+	}
+	require.Equal(t, map[string]int{
+		"#": 1, "mtllib": 1, "o": 1, "usemtl": 2,
+		"v": 24, "vt": 24, "vn": 24, "f": 12,
+	}, counts)
+	require.Equal(t, 2, strings.Count(mtl.String(), "newmtl "))
+}
+
+func TestWriteGLTF(t *testing.T) {
+	scene, err := mesh.New(bsptest.NewRoom(t), mesh.Options{})
+	require.NoError(t, err)
+	scene.Materials[0].Path = "textures/" + scene.Materials[0].Name + ".png"
+
+	var buf bytes.Buffer
+	require.NoError(t, scene.WriteGLTF(&buf))
+
+	var doc struct {
+		Meshes []struct {
+			Primitives []struct {
+				Indices int `json:"indices"`
+			} `json:"primitives"`
+		} `json:"meshes"`
+		Images    []struct{ URI string } `json:"images"`
+		Accessors []struct {
+			Count int `json:"count"`
+		} `json:"accessors"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+
+	require.Len(t, doc.Meshes, 1)
+	var indices int
+	for _, prim := range doc.Meshes[0].Primitives {
+		indices += doc.Accessors[prim.Indices].Count
+	}
+	require.Equal(t, 12*3, indices)
+	require.Len(t, doc.Images, 1)
+	require.Equal(t, scene.Materials[0].Path, doc.Images[0].URI)
+}
+
+func TestNewLightmaps(t *testing.T) {
+	scene, err := mesh.New(bsptest.NewRoom(t), mesh.Options{Lightmaps: true})
+	require.NoError(t, err)
+
+	require.Len(t, scene.Materials, 1)
+	require.Equal(t, mesh.LightmapMaterial, scene.Materials[0].Name)
+	require.NotNil(t, scene.Materials[0].Image)
+
+	require.Len(t, scene.Meshes[0].Surfaces, 1)
+	for _, v := range scene.Meshes[0].Surfaces[0].Vertices {
+		for _, coord := range v.UV {
+			require.True(t, coord > 0 && coord < 1, "UV %v is inside the atlas", v.UV)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package mesh
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Writes the scene as a Wavefront OBJ file referencing the given MTL file.
+func (scene *Scene) WriteOBJ(w io.Writer, mtllib string) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "# goldutil")
+	fmt.Fprintf(bw, "mtllib %s\n", mtllib)
+
+	var offset = 1 // OBJ indexes start at 1 and are global to the file
+	for _, mesh := range scene.Meshes {
+		fmt.Fprintf(bw, "o %s\n", mesh.Name)
+
+		for _, surface := range mesh.Surfaces {
+			for _, v := range surface.Vertices {
+				fmt.Fprintf(bw, "v %s %s %s\n", ftoa(v.Position.X), ftoa(v.Position.Y), ftoa(v.Position.Z))
+			}
+			for _, v := range surface.Vertices {
+				// OBJ texture origin is at the bottom left.
+				fmt.Fprintf(bw, "vt %s %s\n", ftoa(v.UV[0]), ftoa(1-v.UV[1]))
+			}
+			for _, v := range surface.Vertices {
+				fmt.Fprintf(bw, "vn %s %s %s\n", ftoa(v.Normal.X), ftoa(v.Normal.Y), ftoa(v.Normal.Z))
+			}
+
+			fmt.Fprintf(bw, "usemtl %s\n", scene.Materials[surface.Material].Name)
+			for i := 0; i < len(surface.Indices); i += 3 {
+				fmt.Fprint(bw, "f")
+				for _, index := range surface.Indices[i : i+3] {
+					fmt.Fprintf(bw, " %[1]d/%[1]d/%[1]d", offset+int(index))
+				}
+				fmt.Fprintln(bw)
+			}
+
+			offset += len(surface.Vertices)
+		}
+	}
+
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("unable to write OBJ: %w", err)
+	}
+
+	return nil
+}
+
+// Writes the scene materials as a Wavefront MTL file.
+func (scene *Scene) WriteMTL(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "# goldutil")
+
+	for _, mat := range scene.Materials {
+		fmt.Fprintf(bw, "\nnewmtl %s\n", mat.Name)
+		fmt.Fprintln(bw, "Ka 0 0 0")
+		fmt.Fprintln(bw, "Kd 1 1 1")
+		fmt.Fprintln(bw, "Ks 0 0 0")
+		fmt.Fprintln(bw, "illum 1")
+
+		if mat.Path == "" {
+			continue
+		}
+
+		fmt.Fprintf(bw, "map_Kd %s\n", mat.Path)
+		if mat.IsTransparent() {
+			fmt.Fprintf(bw, "map_d %s\n", mat.Path)
+		}
+	}
+
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("unable to write MTL: %w", err)
+	}
+
+	return nil
+}
+
+func ftoa(f float32) string {
+	return strconv.FormatFloat(float64(f), 'f', -1, 32)
+}
@@ -0,0 +1,46 @@
+package bsp_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/L-P/goldutil/goldsrc/bsp"
+	"github.com/L-P/goldutil/goldsrc/wad"
+	"github.com/L-P/goldutil/internal/bsptest"
+)
+
+func TestCompactTexInfo(t *testing.T) {
+	b := bsptest.NewRoom(t)
+
+	// An unused record, and a duplicate of the floor used by the ceiling.
+	b.TexInfo = append(b.TexInfo, b.TexInfo[0], b.TexInfo[2])
+	b.Faces[5].TexInfo = 4
+
+	require.Equal(t, 2, b.CompactTexInfo())
+	require.Equal(t, bsptest.NewRoom(t).TexInfo, b.TexInfo)
+	require.Equal(t, int16(2), b.Faces[5].TexInfo)
+}
+
+func TestRemoveUnusedTextures(t *testing.T) {
+	b := bsptest.NewRoom(t)
+
+	for _, name := range []string{"unused", "+0lights", "+1lights", "+alights", "+0other", "-1random"} {
+		tex, err := wad.NewMIPTexture(name, 16, 16)
+		require.NoError(t, err)
+		b.Textures.Textures = append(b.Textures.Textures, tex)
+	}
+	b.TexInfo[0].MIPTex = 3 // +0lights instead of wall
+	b.TexInfo[1].MIPTex = 3
+	b.TexInfo = append(b.TexInfo, bsp.TexInfo{MIPTex: 7}) // -1random
+
+	require.Equal(t, []string{"wall", "unused", "+0other"}, b.RemoveUnusedTextures())
+
+	var names []string
+	for _, tex := range b.Textures.Textures {
+		names = append(names, tex.Name.String())
+	}
+	require.Equal(t, []string{"floor", "+0lights", "+1lights", "+alights", "-1random"}, names)
+	require.Equal(t, int32(1), b.TexInfo[1].MIPTex)
+	require.Equal(t, int32(4), b.TexInfo[3].MIPTex)
+}
@@ -0,0 +1,382 @@
+// Package render draws 2D views of BSP maps.
+package render
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/L-P/goldutil/goldsrc/bsp"
+	"github.com/L-P/goldutil/goldsrc/wad"
+)
+
+// Size of the overviews the HLTV spectator view expects.
+const (
+	OverviewWidth  = 1024
+	OverviewHeight = 768
+)
+
+// World units covered by the width of an overview at zoom 1.
+const hltvSpan = 8192
+
+type OverviewOptions struct {
+	Width, Height int // defaults to OverviewWidth and OverviewHeight
+
+	// Use textures instead of colors by height, textures that are not
+	// embedded are looked up in WADs.
+	Textured bool
+	WADs     wad.Collection
+
+	// Draw spawn points and point entities.
+	Markers bool
+}
+
+// Orthographic top-down view of the world model, north (+Y) is up.
+type Overview struct {
+	Image  *image.RGBA
+	Zoom   float32
+	Origin bsp.Vec3f // world position of the image center, Z is the lowest point of the world
+}
+
+type overviewRenderer struct {
+	bsp      *bsp.BSP
+	opts     OverviewOptions
+	ov       Overview
+	depth    []float32
+	scale    float32 // world units per pixel
+	minZ     float32
+	maxZ     float32
+	textures map[int32]image.Image
+}
+
+func NewOverview(b *bsp.BSP, opts OverviewOptions) (*Overview, error) {
+	if len(b.Models) == 0 {
+		return nil, errors.New("BSP has no world model")
+	}
+
+	if opts.Width <= 0 || opts.Height <= 0 {
+		opts.Width, opts.Height = OverviewWidth, OverviewHeight
+	}
+
+	var (
+		world = b.Models[0]
+		spanX = world.Maxs.X - world.Mins.X
+		spanY = world.Maxs.Y - world.Mins.Y
+	)
+	if spanX <= 0 || spanY <= 0 {
+		return nil, errors.New("world model has an empty bounding box")
+	}
+
+	// Keep a 5% margin around the world.
+	scale := max(spanX/float32(opts.Width), spanY/float32(opts.Height)) * 1.05
+
+	r := overviewRenderer{
+		bsp:      b,
+		opts:     opts,
+		scale:    scale,
+		minZ:     world.Mins.Z,
+		maxZ:     world.Maxs.Z,
+		depth:    make([]float32, opts.Width*opts.Height),
+		textures: map[int32]image.Image{},
+		ov: Overview{
+			Image: image.NewRGBA(image.Rect(0, 0, opts.Width, opts.Height)),
+			Zoom:  hltvSpan / (float32(opts.Width) * scale),
+			Origin: bsp.Vec3f{
+				X: (world.Mins.X + world.Maxs.X) / 2,
+				Y: (world.Mins.Y + world.Maxs.Y) / 2,
+				Z: world.Mins.Z,
+			},
+		},
+	}
+
+	for i := range r.depth {
+		r.depth[i] = float32(math.Inf(-1))
+		r.ov.Image.Pix[i*4+3] = 0xFF
+	}
+
+	for face := world.FirstFace; face < world.FirstFace+world.NumFaces; face++ {
+		if err := r.drawFace(int(face)); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.Markers {
+		if err := r.drawMarkers(); err != nil {
+			return nil, err
+		}
+	}
+
+	return &r.ov, nil
+}
+
+// Returns the image position of a world position.
+func (r *overviewRenderer) project(p bsp.Vec3f) (float32, float32) {
+	return (p.X-r.ov.Origin.X)/r.scale + float32(r.opts.Width)/2,
+		(r.ov.Origin.Y-p.Y)/r.scale + float32(r.opts.Height)/2
+}
+
+// Returns the world position of an image position, at Z 0.
+func (r *overviewRenderer) unproject(x, y float32) bsp.Vec3f {
+	return bsp.Vec3f{
+		X: r.ov.Origin.X + (x-float32(r.opts.Width)/2)*r.scale,
+		Y: r.ov.Origin.Y - (y-float32(r.opts.Height)/2)*r.scale,
+	}
+}
+
+// Draws faces seen from above, keeping the highest one of each pixel.
+func (r *overviewRenderer) drawFace(face int) error {
+	normal, err := r.bsp.FaceNormal(face)
+	if err != nil {
+		return err
+	}
+	if normal.Z <= 0 {
+		return nil
+	}
+
+	tex, err := r.bsp.FaceTexture(face)
+	if err != nil {
+		return err
+	}
+	if strings.HasPrefix(strings.ToLower(tex.Name.String()), "sky") {
+		return nil
+	}
+
+	points, err := r.bsp.FaceVertices(face)
+	if err != nil {
+		return err
+	}
+
+	texInfo := r.bsp.TexInfo[r.bsp.Faces[face].TexInfo]
+	img, err := r.texture(texInfo.MIPTex)
+	if err != nil {
+		return err
+	}
+
+	var (
+		plane = r.bsp.Planes[r.bsp.Faces[face].Plane]
+		poly  = make([][2]float32, len(points))
+		minX  = float32(math.Inf(1))
+		minY  = float32(math.Inf(1))
+		maxX  = float32(math.Inf(-1))
+		maxY  = float32(math.Inf(-1))
+	)
+
+	for i, p := range points {
+		x, y := r.project(p)
+		poly[i] = [2]float32{x, y}
+		minX, minY = min(minX, x), min(minY, y)
+		maxX, maxY = max(maxX, x), max(maxY, y)
+	}
+
+	x0, y0 := max(0, int(minX)), max(0, int(minY))
+	x1, y1 := min(r.opts.Width-1, int(maxX)), min(r.opts.Height-1, int(maxY))
+	for y := y0; y <= y1; y++ {
+		for x := x0; x <= x1; x++ {
+			px, py := float32(x)+0.5, float32(y)+0.5
+			if !insideConvex(poly, px, py) {
+				continue
+			}
+
+			// Z on the face plane, normal.Z cannot be 0 for faces seen from above.
+			p := r.unproject(px, py)
+			p.Z = (plane.Dist - plane.Normal.X*p.X - plane.Normal.Y*p.Y) / plane.Normal.Z
+
+			i := y*r.opts.Width + x
+			if p.Z <= r.depth[i] {
+				continue
+			}
+			r.depth[i] = p.Z
+
+			c := r.heightColor(p.Z)
+			if img != nil {
+				c = sampleTexture(img, texInfo, p)
+			}
+
+			// Darken slopes.
+			shade := 0.6 + 0.4*normal.Z
+			r.ov.Image.Pix[i*4+0] = uint8(float32(c.R) * shade)
+			r.ov.Image.Pix[i*4+1] = uint8(float32(c.G) * shade)
+			r.ov.Image.Pix[i*4+2] = uint8(float32(c.B) * shade)
+		}
+	}
+
+	return nil
+}
+
+// Returns the rendered texture, nil if textures are not used, missing, or
+// cannot be rendered.
+func (r *overviewRenderer) texture(index int32) (image.Image, error) {
+	if !r.opts.Textured || index < 0 || int(index) >= len(r.bsp.Textures.Textures) {
+		return nil, nil
+	}
+
+	if img, ok := r.textures[index]; ok {
+		return img, nil
+	}
+
+	// Quake textures use the palette of the game, fall back to height colors.
+	tex := r.bsp.Textures.Textures[index]
+	if tex.IsEmbedded() && r.bsp.Variant == bsp.VariantQuake {
+		r.textures[index] = nil
+		return nil, nil
+	}
+
+	if !tex.IsEmbedded() {
+		found, ok := r.opts.WADs.GetTexture(tex.Name.String())
+		if !ok {
+			r.textures[index] = nil
+			return nil, nil
+		}
+		tex = found
+	}
+
+	img, err := tex.Render(false)
+	if err != nil {
+		return nil, fmt.Errorf("unable to render texture %s: %w", tex.Name.String(), err)
+	}
+	r.textures[index] = img
+
+	return img, nil
+}
+
+func sampleTexture(img image.Image, texInfo bsp.TexInfo, p bsp.Vec3f) color.RGBA {
+	var (
+		bounds = img.Bounds()
+		s, t   = texInfo.TexCoords(p)
+		x      = int(math.Floor(float64(s))) % bounds.Dx()
+		y      = int(math.Floor(float64(t))) % bounds.Dy()
+	)
+	if x < 0 {
+		x += bounds.Dx()
+	}
+	if y < 0 {
+		y += bounds.Dy()
+	}
+
+	cr, cg, cb, _ := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+
+	return color.RGBA{uint8(cr >> 8), uint8(cg >> 8), uint8(cb >> 8), 0xFF}
+}
+
+// Gradient from the lowest to the highest point of the world.
+var heightGradient = []color.RGBA{
+	{0x1B, 0x26, 0x4F, 0xFF},
+	{0x2E, 0x86, 0x8C, 0xFF},
+	{0x8F, 0xC9, 0x5A, 0xFF},
+	{0xF2, 0xE8, 0x9B, 0xFF},
+}
+
+func (r *overviewRenderer) heightColor(z float32) color.RGBA {
+	var t float32
+	if r.maxZ > r.minZ {
+		t = min(1, max(0, (z-r.minZ)/(r.maxZ-r.minZ)))
+	}
+
+	pos := t * float32(len(heightGradient)-1)
+	i := min(int(pos), len(heightGradient)-2)
+	a, b, f := heightGradient[i], heightGradient[i+1], pos-float32(i)
+	lerp := func(a, b uint8) uint8 {
+		return uint8(float32(a) + (float32(b)-float32(a))*f)
+	}
+
+	return color.RGBA{lerp(a.R, b.R), lerp(a.G, b.G), lerp(a.B, b.B), 0xFF}
+}
+
+// Returns true if the point is inside the convex polygon, whatever its
+// winding.
+func insideConvex(poly [][2]float32, x, y float32) bool {
+	var pos, neg bool
+	for i := range poly {
+		a, b := poly[i], poly[(i+1)%len(poly)]
+		cross := (b[0]-a[0])*(y-a[1]) - (b[1]-a[1])*(x-a[0])
+		pos = pos || cross > 0
+		neg = neg || cross < 0
+		if pos && neg {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Marker colors of spawn points, other point entities are white.
+var spawnColors = map[string]color.RGBA{
+	"info_player_start":      {0x40, 0x80, 0xFF, 0xFF}, // single-player and CT
+	"info_player_deathmatch": {0xFF, 0x40, 0x40, 0xFF}, // multiplayer and T
+	"info_player_coop":       {0x40, 0xFF, 0x40, 0xFF},
+	"info_vip_start":         {0xFF, 0xFF, 0x40, 0xFF},
+}
+
+func (r *overviewRenderer) drawMarkers() error {
+	qm, err := r.bsp.LoadEntities()
+	if err != nil {
+		return err
+	}
+
+	for ent := range qm.Entities() {
+		origin, ok := ent.KVs["origin"]
+		if !ok || strings.HasPrefix(ent.KVs["model"], "*") {
+			continue
+		}
+
+		pos, err := bsp.ParseVec3f(origin)
+		if err != nil {
+			return fmt.Errorf("unable to read origin of %s: %w", ent.KVs["classname"], err)
+		}
+
+		x, y := r.project(pos)
+		if c, ok := spawnColors[ent.KVs["classname"]]; ok {
+			r.disc(x, y, 5, color.RGBA{0, 0, 0, 0xFF})
+			r.disc(x, y, 4, c)
+		} else {
+			r.disc(x, y, 2, color.RGBA{0xFF, 0xFF, 0xFF, 0xFF})
+		}
+	}
+
+	return nil
+}
+
+func (r *overviewRenderer) disc(cx, cy, radius float32, c color.RGBA) {
+	for y := int(cy - radius); y <= int(cy+radius); y++ {
+		for x := int(cx - radius); x <= int(cx+radius); x++ {
+			dx, dy := float32(x)+0.5-cx, float32(y)+0.5-cy
+			if dx*dx+dy*dy <= radius*radius {
+				r.ov.Image.SetRGBA(x, y, c)
+			}
+		}
+	}
+}
+
+// Writes the HLTV overview description file, image is the path of the image
+// relative to the mod directory (eg. overviews/mapname.tga).
+func (ov *Overview) WriteHLTV(w io.Writer, mapName, image string) error {
+	_, err := fmt.Fprintf(
+		w,
+		"// overview description file for %s.bsp\n\n"+
+			"global\n{\n\tZOOM\t%s\n\tORIGIN\t%s %s %s\n\tROTATED\t0\n}\n\n"+
+			"layer\n{\n\tIMAGE\t\"%s\"\n\tHEIGHT\t%s\n}\n",
+		mapName,
+		ftoa(ov.Zoom),
+		ftoa(ov.Origin.X), ftoa(ov.Origin.Y), ftoa(ov.Origin.Z),
+		image,
+		ftoa(ov.Origin.Z),
+	)
+	if err != nil {
+		return fmt.Errorf("unable to write overview description: %w", err)
+	}
+
+	return nil
+}
+
+func ftoa(f float32) string {
+	if f == 0 {
+		f = 0 // no negative zero
+	}
+
+	return strconv.FormatFloat(float64(f), 'f', 2, 32)
+}
@@ -0,0 +1,82 @@
+package render_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/L-P/goldutil/goldsrc/bsp"
+	"github.com/L-P/goldutil/goldsrc/bsp/render"
+	"github.com/L-P/goldutil/internal/bsptest"
+)
+
+func TestNewOverview(t *testing.T) {
+	ov, err := render.NewOverview(bsptest.NewRoom(t), render.OverviewOptions{})
+	require.NoError(t, err)
+
+	require.Equal(t, image.Rect(0, 0, render.OverviewWidth, render.OverviewHeight), ov.Image.Rect)
+	require.Equal(t, bsp.Vec3f{X: 128, Y: 128, Z: 0}, ov.Origin)
+	require.InDelta(t, 8192/(256*1.05*4/3), ov.Zoom, 0.001)
+
+	// Only the floor is seen from above, outside the room is black.
+	require.Equal(t, color.RGBA{0x1B, 0x26, 0x4F, 0xFF}, ov.Image.RGBAAt(512, 384))
+	require.Equal(t, color.RGBA{0, 0, 0, 0xFF}, ov.Image.RGBAAt(0, 0))
+
+	var b bytes.Buffer
+	require.NoError(t, ov.WriteHLTV(&b, "room", "overviews/room.tga"))
+	require.Equal(t, `// overview description file for room.bsp
+
+global
+{
+	ZOOM	22.86
+	ORIGIN	128.00 128.00 0.00
+	ROTATED	0
+}
+
+layer
+{
+	IMAGE	"overviews/room.tga"
+	HEIGHT	0.00
+}
+`, b.String())
+}
+
+func TestNewOverviewQuake(t *testing.T) {
+	room := bsptest.NewRoom(t)
+	room.Variant = bsp.VariantQuake
+
+	textured, err := render.NewOverview(room, render.OverviewOptions{Textured: true})
+	require.NoError(t, err)
+
+	untextured, err := render.NewOverview(room, render.OverviewOptions{})
+	require.NoError(t, err)
+	require.Equal(t, untextured.Image.Pix, textured.Image.Pix)
+}
+
+func TestNewOverviewMarkers(t *testing.T) {
+	ov, err := render.NewOverview(bsptest.NewRoom(t), render.OverviewOptions{
+		Width:   256,
+		Height:  256,
+		Markers: true,
+	})
+	require.NoError(t, err)
+
+	// info_player_start is in the center of the room.
+	require.Equal(t, color.RGBA{0x40, 0x80, 0xFF, 0xFF}, ov.Image.RGBAAt(128, 128))
+}
+
+func TestWriteTGA(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	img.SetRGBA(0, 0, color.RGBA{0x01, 0x02, 0x03, 0xFF})
+
+	var b bytes.Buffer
+	require.NoError(t, render.WriteTGA(&b, img))
+	require.Equal(t, []byte{
+		0, 0, 2, 0, 0, 0, 0, 0, 0, 0, 0, 0, 2, 0, 2, 0, 24, 0,
+		0, 0, 0, 0, 0, 0, // bottom row first
+		0x03, 0x02, 0x01, 0, 0, 0, // BGR
+	}, b.Bytes())
+}
@@ -0,0 +1,273 @@
+package render
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/L-P/goldutil/goldsrc/bsp"
+	"github.com/L-P/goldutil/goldsrc/qmap"
+)
+
+type PlanOptions struct {
+	// If Slice is set, only draw the world edges, triggers, and point
+	// entities that are in the MinZ to MaxZ height range.
+	Slice      bool
+	MinZ, MaxZ float32
+}
+
+// Margin around the world in the plan, in world units.
+const planMargin = 64
+
+const planStyle = `
+.world { stroke: #222; stroke-width: 1; fill: none; stroke-linecap: round }
+.trigger { stroke: #d60; stroke-width: 2; stroke-dasharray: 8 4; fill: #f902 }
+.trigger:hover, .entity:hover { opacity: 0.6 }
+.entity text { font: 12px sans-serif; fill: #000; paint-order: stroke; stroke: #fff; stroke-width: 3 }
+`
+
+type planWriter struct {
+	bsp  *bsp.BSP
+	opts PlanOptions
+	b    strings.Builder
+}
+
+// Writes a top-down SVG plan of the world edges, trigger brush models, and
+// point entities. North (+Y) is up, one SVG unit is one world unit.
+// Triggers and entities have their KVs as a tooltip.
+func WritePlan(w io.Writer, b *bsp.BSP, opts PlanOptions) error {
+	if len(b.Models) == 0 {
+		return errors.New("BSP has no world model")
+	}
+
+	if !opts.Slice {
+		opts.MinZ, opts.MaxZ = b.Models[0].Mins.Z, b.Models[0].Maxs.Z
+	}
+
+	qm, err := b.LoadEntities()
+	if err != nil {
+		return err
+	}
+
+	var (
+		pw    = planWriter{bsp: b, opts: opts}
+		world = b.Models[0]
+	)
+	fmt.Fprintf(
+		&pw.b,
+		`<svg xmlns="http://www.w3.org/2000/svg" viewBox="%s %s %s %s">`+"\n<style>%s</style>\n",
+		ftoa(world.Mins.X-planMargin), ftoa(-world.Maxs.Y-planMargin),
+		ftoa(world.Maxs.X-world.Mins.X+2*planMargin), ftoa(world.Maxs.Y-world.Mins.Y+2*planMargin),
+		planStyle,
+	)
+
+	if err := pw.writeWorld(); err != nil {
+		return err
+	}
+
+	if err := pw.writeTriggers(qm); err != nil {
+		return err
+	}
+
+	if err := pw.writeEntities(qm); err != nil {
+		return err
+	}
+
+	pw.b.WriteString("</svg>\n")
+
+	if _, err := io.WriteString(w, pw.b.String()); err != nil {
+		return fmt.Errorf("unable to write plan: %w", err)
+	}
+
+	return nil
+}
+
+func (pw *planWriter) inRange(minZ, maxZ float32) bool {
+	return maxZ >= pw.opts.MinZ && minZ <= pw.opts.MaxZ
+}
+
+// Draws each edge of the world faces once as seen from above, vertical
+// edges are skipped as they are a single point.
+func (pw *planWriter) writeWorld() error {
+	var (
+		world = pw.bsp.Models[0]
+		seen  = map[[4]float32]bool{} // projected segments, walls stack edges
+	)
+
+	pw.b.WriteString(`<path class="world" d="`)
+	for face := world.FirstFace; face < world.FirstFace+world.NumFaces; face++ {
+		if face < 0 || int(face) >= len(pw.bsp.Faces) {
+			return fmt.Errorf("world model: face %d does not exist", face)
+		}
+
+		f := pw.bsp.Faces[face]
+		for i := f.FirstEdge; i < f.FirstEdge+int32(f.NumEdges); i++ {
+			if i < 0 || int(i) >= len(pw.bsp.SurfEdges) {
+				return fmt.Errorf("face %d: surfedge %d does not exist", face, i)
+			}
+
+			edge := int64(pw.bsp.SurfEdges[i]) // widened so negating MinInt32 does not overflow
+			edge = max(edge, -edge)
+			if edge >= int64(len(pw.bsp.Edges)) {
+				return fmt.Errorf("face %d: edge %d does not exist", face, edge)
+			}
+
+			a, b := pw.bsp.Edges[edge][0], pw.bsp.Edges[edge][1]
+			if int(a) >= len(pw.bsp.Vertices) || int(b) >= len(pw.bsp.Vertices) {
+				return fmt.Errorf("edge %d: vertex does not exist", edge)
+			}
+
+			va, vb := pw.bsp.Vertices[a], pw.bsp.Vertices[b]
+			if (va.X == vb.X && va.Y == vb.Y) || !pw.inRange(min(va.Z, vb.Z), max(va.Z, vb.Z)) {
+				continue
+			}
+
+			if va.X > vb.X || (va.X == vb.X && va.Y > vb.Y) {
+				va, vb = vb, va
+			}
+			key := [4]float32{va.X, va.Y, vb.X, vb.Y}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			fmt.Fprintf(&pw.b, "M%s %sL%s %s", ftoa(va.X), ftoa(-va.Y), ftoa(vb.X), ftoa(-vb.Y))
+		}
+	}
+	pw.b.WriteString("\"/>\n")
+
+	return nil
+}
+
+// Draws the footprint of trigger brush models using their upward faces.
+func (pw *planWriter) writeTriggers(qm *qmap.QMap) error {
+	for ent := range qm.Entities() {
+		if !strings.HasPrefix(ent.KVs["classname"], "trigger_") {
+			continue
+		}
+
+		index, err := strconv.Atoi(strings.TrimPrefix(ent.KVs["model"], "*"))
+		if err != nil || index < 1 || index >= len(pw.bsp.Models) {
+			continue
+		}
+
+		var origin bsp.Vec3f
+		if str, ok := ent.KVs["origin"]; ok {
+			if origin, err = bsp.ParseVec3f(str); err != nil {
+				return fmt.Errorf("unable to read origin of %s: %w", ent.KVs["classname"], err)
+			}
+		}
+
+		model := pw.bsp.Models[index]
+		if !pw.inRange(model.Mins.Z+origin.Z, model.Maxs.Z+origin.Z) {
+			continue
+		}
+
+		fmt.Fprintf(&pw.b, `<g class="trigger"><title>%s</title>`, escape(formatKVs(ent.KVs)))
+		for face := model.FirstFace; face < model.FirstFace+model.NumFaces; face++ {
+			normal, err := pw.bsp.FaceNormal(int(face))
+			if err != nil {
+				return err
+			}
+			if normal.Z <= 0 {
+				continue
+			}
+
+			points, err := pw.bsp.FaceVertices(int(face))
+			if err != nil {
+				return err
+			}
+
+			pw.b.WriteString(`<polygon points="`)
+			for i, p := range points {
+				if i > 0 {
+					pw.b.WriteByte(' ')
+				}
+				fmt.Fprintf(&pw.b, "%s,%s", ftoa(p.X+origin.X), ftoa(-(p.Y + origin.Y)))
+			}
+			pw.b.WriteString(`"/>`)
+		}
+		pw.b.WriteString("</g>\n")
+	}
+
+	return nil
+}
+
+// An icon shape and color, by classname prefix.
+type planIcon struct {
+	prefix string
+	shape  string // SVG element drawn centered on (0, 0)
+}
+
+var planIcons = []planIcon{
+	{"info_player_", `<circle r="12" fill="#2a2"/>`},
+	{"light", `<circle r="8" fill="#fc0"/>`},
+	{"monster_", `<rect x="-10" y="-10" width="20" height="20" fill="#c22"/>`},
+	{"weapon_", `<path d="M0-12L12 0L0 12L-12 0Z" fill="#26c"/>`},
+	{"ammo_", `<path d="M0-10L10 0L0 10L-10 0Z" fill="#6ac"/>`},
+	{"item_", `<path d="M0-10L10 0L0 10L-10 0Z" fill="#a6c"/>`},
+}
+
+const defaultPlanIcon = `<circle r="6" fill="#888"/>`
+
+func (pw *planWriter) writeEntities(qm *qmap.QMap) error {
+	for ent := range qm.Entities() {
+		str, ok := ent.KVs["origin"]
+		if !ok || strings.HasPrefix(ent.KVs["model"], "*") {
+			continue
+		}
+
+		origin, err := bsp.ParseVec3f(str)
+		if err != nil {
+			return fmt.Errorf("unable to read origin of %s: %w", ent.KVs["classname"], err)
+		}
+		if !pw.inRange(origin.Z, origin.Z) {
+			continue
+		}
+
+		var (
+			class = ent.KVs["classname"]
+			icon  = defaultPlanIcon
+		)
+		for _, v := range planIcons {
+			if strings.HasPrefix(class, v.prefix) {
+				icon = v.shape
+				break
+			}
+		}
+
+		fmt.Fprintf(
+			&pw.b,
+			`<g class="entity" transform="translate(%s %s)"><title>%s</title>%s<text x="14" y="4">%s</text></g>`+"\n",
+			ftoa(origin.X), ftoa(-origin.Y),
+			escape(formatKVs(ent.KVs)), icon, escape(class),
+		)
+	}
+
+	return nil
+}
+
+// Formats KVs the way they appear in .ent files, sorted by key.
+func formatKVs(kvs map[string]string) string {
+	var b strings.Builder
+	for i, k := range slices.Sorted(maps.Keys(kvs)) {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		fmt.Fprintf(&b, `"%s" "%s"`, k, kvs[k])
+	}
+
+	return b.String()
+}
+
+func escape(str string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(str)) // strings.Builder never fails
+
+	return b.String()
+}
@@ -0,0 +1,74 @@
+package render_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/L-P/goldutil/goldsrc/bsp/render"
+	"github.com/L-P/goldutil/internal/bsptest"
+)
+
+func TestWritePlan(t *testing.T) {
+	b := bsptest.NewRoom(t)
+
+	// Turn the floor into a trigger.
+	trigger := b.Models[0]
+	trigger.FirstFace, trigger.NumFaces = 4, 1
+	trigger.Maxs.Z = 64
+	b.Models = append(b.Models, trigger)
+	b.Entities = append(bytes.TrimRight(b.Entities, "\x00"), []byte("{\n"+
+		`"classname" "trigger_once"`+"\n"+
+		`"model" "*1"`+"\n"+
+		`"target" "a&b"`+"\n"+
+		"}\n\x00")...)
+
+	var out bytes.Buffer
+	require.NoError(t, render.WritePlan(&out, b, render.PlanOptions{}))
+	svg := out.String()
+
+	// Must be valid XML.
+	decoder := xml.NewDecoder(strings.NewReader(svg))
+	for {
+		if _, err := decoder.Token(); err != nil {
+			require.ErrorContains(t, err, "EOF")
+			break
+		}
+	}
+
+	require.Contains(t, svg, `viewBox="-64.00 -320.00 384.00 384.00"`)
+	// 12 edges, 4 are vertical and the floor and ceiling overlap.
+	require.Equal(t, 4, strings.Count(svg, "M"))
+	require.Contains(t, svg, `<g class="trigger"><title>&#34;classname&#34; &#34;trigger_once&#34;&#xA;&#34;model&#34; &#34;*1&#34;&#xA;&#34;target&#34; &#34;a&amp;b&#34;</title><polygon points="0.00,0.00 0.00,-256.00 256.00,-256.00 256.00,0.00"/></g>`)
+	require.Contains(t, svg, `<g class="entity" transform="translate(128.00 -128.00)">`)
+	require.Contains(t, svg, `<text x="14" y="4">info_player_start</text>`)
+
+	out.Reset()
+	require.NoError(t, render.WritePlan(&out, b, render.PlanOptions{Slice: true, MinZ: 100, MaxZ: 200}))
+	svg = out.String()
+	require.Equal(t, 0, strings.Count(svg, "M"))
+	require.NotContains(t, svg, "trigger_once")
+	require.NotContains(t, svg, "info_player_start")
+
+	// A slice at 0 is not the whole map.
+	out.Reset()
+	require.NoError(t, render.WritePlan(&out, b, render.PlanOptions{Slice: true}))
+	svg = out.String()
+	require.Equal(t, 4, strings.Count(svg, "M"))
+	require.NotContains(t, svg, "info_player_start")
+}
+
+func TestWritePlanInvalidFaces(t *testing.T) {
+	b := bsptest.NewRoom(t)
+	b.Models[0].NumFaces++
+
+	require.ErrorContains(t, render.WritePlan(&bytes.Buffer{}, b, render.PlanOptions{}), "face 6 does not exist")
+
+	b = bsptest.NewRoom(t)
+	b.SurfEdges[b.Faces[0].FirstEdge] = math.MinInt32
+	require.ErrorContains(t, render.WritePlan(&bytes.Buffer{}, b, render.PlanOptions{}), "does not exist")
+}
@@ -0,0 +1,64 @@
+package render
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"io"
+)
+
+// Binary-accurate.
+type tgaHeader struct {
+	IDLength     uint8
+	ColorMapType uint8
+	ImageType    uint8
+	ColorMap     [5]byte
+	XOrigin      uint16
+	YOrigin      uint16
+	Width        uint16
+	Height       uint16
+	PixelDepth   uint8
+	Descriptor   uint8
+}
+
+const tgaTrueColor = 2
+
+// Writes an uncompressed 24-bit TGA, the format the engine reads overviews
+// from. Rows are stored bottom to top as most readers expect by default.
+func WriteTGA(w io.Writer, img image.Image) error {
+	bounds := img.Bounds()
+	if bounds.Dx() > 0xFFFF || bounds.Dy() > 0xFFFF {
+		return fmt.Errorf("image is too large for TGA: %dx%d", bounds.Dx(), bounds.Dy())
+	}
+
+	bw := bufio.NewWriter(w)
+	header := tgaHeader{
+		ImageType:  tgaTrueColor,
+		Width:      uint16(bounds.Dx()),
+		Height:     uint16(bounds.Dy()),
+		PixelDepth: 24,
+	}
+	if err := binary.Write(bw, binary.LittleEndian, header); err != nil {
+		return fmt.Errorf("unable to write TGA header: %w", err)
+	}
+
+	row := make([]byte, bounds.Dx()*3)
+	for y := bounds.Max.Y - 1; y >= bounds.Min.Y; y-- {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, _ := img.At(x, y).RGBA()
+			i := (x - bounds.Min.X) * 3
+			row[i], row[i+1], row[i+2] = uint8(b>>8), uint8(g>>8), uint8(r>>8)
+		}
+
+		if _, err := bw.Write(row); err != nil {
+			return fmt.Errorf("unable to write TGA data: %w", err)
+		}
+	}
+
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("unable to write TGA data: %w", err)
+	}
+
+	return nil
+}
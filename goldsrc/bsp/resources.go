@@ -0,0 +1,129 @@
+package bsp
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/L-P/goldutil/internal/set"
+)
+
+// Entity keys that can hold the path to a model, sprite, or sound, keys
+// starting with "noise" are checked too.
+var resourceKeys = []string{"model", "texture", "sprite", "shootmodel", "gibmodel", "message", "sentence"}
+
+var skySuffixes = []string{"up", "dn", "lf", "rt", "ft", "bk"}
+
+const (
+	SentencesPath = "sound/sentences.txt"
+	TitlesPath    = "titles.txt"
+)
+
+// Returns the sorted paths, relative to the mod directory, of the files
+// referenced by the map entities: models, sprites, sounds, sky, and WADs.
+// Paths are cleaned, paths that are still invalid according to fs.ValidPath
+// are broken references.
+// Sentences ("!NAME") and env_message titles add SentencesPath and
+// TitlesPath as they are resolved by the engine from those files.
+func (bsp *BSP) Resources() ([]string, error) {
+	qm, err := bsp.LoadEntities()
+	if err != nil {
+		return nil, err
+	}
+
+	wads, err := bsp.WADNames()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := set.NewPresenceSet[string](len(wads))
+	for _, v := range wads {
+		seen.Set(v)
+	}
+
+	for ent := range qm.Entities() {
+		if sky := ent.KVs["skyname"]; ent.KVs["classname"] == "worldspawn" && sky != "" {
+			for _, suffix := range skySuffixes {
+				seen.Set(cleanResourcePath("gfx/env/" + sky + suffix + ".tga"))
+			}
+		}
+
+		if ent.KVs["classname"] == "env_message" && ent.KVs["message"] != "" {
+			seen.Set(TitlesPath)
+		}
+
+		for k, v := range ent.KVs {
+			if !slices.Contains(resourceKeys, k) && !strings.HasPrefix(k, "noise") {
+				continue
+			}
+
+			if res := resourcePath(v); res != "" {
+				seen.Set(res)
+			}
+		}
+	}
+
+	return slices.Sorted(maps.Keys(seen)), nil
+}
+
+// Returns the path of a KV value relative to the mod directory, or an empty
+// string if the value does not reference a file.
+func resourcePath(value string) string {
+	value = strings.ReplaceAll(strings.TrimSpace(value), `\`, "/")
+	if strings.HasPrefix(value, "!") {
+		return SentencesPath
+	}
+
+	switch strings.ToLower(path.Ext(value)) {
+	case ".wav":
+		return cleanResourcePath("sound/" + value)
+	case ".mdl", ".spr":
+		return cleanResourcePath(value)
+	}
+
+	return ""
+}
+
+// Cleans a path and removes its leading slashes.
+func cleanResourcePath(value string) string {
+	return path.Clean(strings.TrimLeft(value, "/"))
+}
+
+// Splits resources between the paths that are valid according to
+// fs.ValidPath and those that are not and would be outside the mod directory.
+func ValidResources(resources []string) ([]string, []string) {
+	var valid, invalid []string
+	for _, v := range resources {
+		if fs.ValidPath(v) {
+			valid = append(valid, v)
+		} else {
+			invalid = append(invalid, v)
+		}
+	}
+
+	return valid, invalid
+}
+
+// Writes a .res file listing the given resources for the engine to send to
+// clients that don't have them. Invalid paths are refused, see
+// ValidResources.
+func WriteRES(w io.Writer, resources []string) error {
+	var b strings.Builder
+	b.WriteString("// generated by goldutil\n")
+	for _, v := range resources {
+		if !fs.ValidPath(v) {
+			return fmt.Errorf("invalid resource path: %s", v)
+		}
+		b.WriteString(v + "\n")
+	}
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("unable to write .res file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package bsp_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/L-P/goldutil/goldsrc/bsp"
+	"github.com/L-P/goldutil/internal/bsptest"
+)
+
+func TestResources(t *testing.T) {
+	b := bsptest.NewRoom(t)
+	require.NoError(t, b.ImportEntities(strings.NewReader(`{
+"classname" "worldspawn"
+"wad" "\half-life\valve\halflife.wad;test.wad"
+"skyname" "night"
+}
+{
+"classname" "ambient_generic"
+"message" "ambience\drips.wav"
+}
+{
+"classname" "env_sprite"
+"model" "sprites/glow01.spr"
+}
+{
+"classname" "func_door"
+"noise1" "doors/doormove1.wav"
+"movesnd" "3"
+}
+{
+"classname" "scripted_sentence"
+"sentence" "!HG_ALERT"
+}
+{
+"classname" "env_message"
+"message" "INTRO"
+}
+{
+"classname" "game_text"
+"message" "Literal text"
+}
+{
+"classname" "env_model"
+"model" "/models\\..\\models/./tree.mdl"
+"noise" "../../outside.wav"
+}
+{
+"classname" "env_sprite"
+"model" "../outside.spr"
+}
+`)))
+
+	res, err := b.Resources()
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"../outside.spr",
+		"../outside.wav",
+		"gfx/env/nightbk.tga",
+		"gfx/env/nightdn.tga",
+		"gfx/env/nightft.tga",
+		"gfx/env/nightlf.tga",
+		"gfx/env/nightrt.tga",
+		"gfx/env/nightup.tga",
+		"halflife.wad",
+		"models/tree.mdl",
+		"sound/ambience/drips.wav",
+		"sound/doors/doormove1.wav",
+		"sound/sentences.txt",
+		"sprites/glow01.spr",
+		"test.wad",
+		"titles.txt",
+	}, res)
+
+	valid, invalid := bsp.ValidResources(res)
+	require.Equal(t, res[2:], valid)
+	require.Equal(t, []string{"../outside.spr", "../outside.wav"}, invalid)
+
+	var buf bytes.Buffer
+	require.NoError(t, bsp.WriteRES(&buf, res[8:10]))
+	require.Equal(t, "// generated by goldutil\nhalflife.wad\nmodels/tree.mdl\n", buf.String())
+
+	require.ErrorContains(t, bsp.WriteRES(&bytes.Buffer{}, res), "../outside.spr")
+}
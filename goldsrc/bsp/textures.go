@@ -0,0 +1,417 @@
+package bsp
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"slices"
+	"strings"
+
+	"github.com/L-P/goldutil/goldsrc"
+	"github.com/L-P/goldutil/goldsrc/wad"
+)
+
+// Copies the data of the textures that are not embedded from the given WADs.
+// Returns the names of the textures that could not be found.
+func (bsp *BSP) EmbedTextures(wads wad.Collection) []string {
+	var missing []string
+	for i, tex := range bsp.Textures.Textures {
+		if tex.IsEmbedded() {
+			continue
+		}
+
+		found, ok := wads.GetTexture(tex.Name.String())
+		if !ok {
+			missing = append(missing, tex.Name.String())
+			continue
+		}
+
+		// Keep the name the BSP uses, WADs can differ in case.
+		found.Name = tex.Name
+		bsp.Textures.Textures[i] = found
+	}
+
+	return missing
+}
+
+// Removes the worldspawn "wad" key so the engine does not load any WAD.
+func (bsp *BSP) RemoveWADKey() error {
+	qm, err := bsp.LoadEntities()
+	if err != nil {
+		return err
+	}
+
+	worldspawn := qm.FindByKV("classname", "worldspawn")
+	if len(worldspawn) != 1 {
+		return fmt.Errorf("expected a single worldspawn, got %d", len(worldspawn))
+	}
+	delete(worldspawn[0].Entity.KVs, "wad")
+
+	return bsp.SetEntities(qm)
+}
+
+// Returns the index of a texture in the lump, ignoring case.
+func (lump *TextureLump) Find(name string) (int, bool) {
+	for i, tex := range lump.Textures {
+		if strings.EqualFold(tex.Name.String(), name) {
+			return i, true
+		}
+	}
+
+	return 0, false
+}
+
+// Replaces a texture while keeping its name.
+// When the dimensions change, texture coordinates are scaled so the texture
+// covers the same area. Lightmaps being mapped using the same coordinates,
+// the lightmaps of the faces using the texture are resampled to match and
+// the lighting lump is rebuilt. Nothing is modified if a scaled surface
+// would exceed MaxSurfaceExtent.
+func (bsp *BSP) ReplaceTexture(name string, tex wad.MIPTexture) error {
+	index, ok := bsp.Textures.Find(name)
+	if !ok {
+		return fmt.Errorf("texture %s does not exist", name)
+	}
+
+	old := bsp.Textures.Textures[index]
+	tex.Name = old.Name
+	if tex.Width == old.Width && tex.Height == old.Height {
+		bsp.Textures.Textures[index] = tex
+		return nil
+	}
+
+	scale := [2]float32{
+		float32(tex.Width) / float32(old.Width),
+		float32(tex.Height) / float32(old.Height),
+	}
+
+	texInfo := slices.Clone(bsp.TexInfo)
+	for i := range texInfo {
+		if texInfo[i].MIPTex != int32(index) {
+			continue
+		}
+
+		for axis, v := range []*TexAxis{&texInfo[i].S, &texInfo[i].T} {
+			v.Vec = v.Vec.Scale(scale[axis])
+			v.Offset *= scale[axis]
+		}
+	}
+
+	replaced := map[int][]byte{} // face => resampled lighting samples
+	for i, face := range bsp.Faces {
+		if face.TexInfo < 0 || int(face.TexInfo) >= len(texInfo) || texInfo[face.TexInfo].MIPTex != int32(index) {
+			continue
+		}
+
+		extents, err := bsp.faceLightmapExtents(i, texInfo[face.TexInfo])
+		if err != nil {
+			return err
+		}
+		if texInfo[face.TexInfo].Flags&TexInfoFlagSpecial == 0 && !extents.IsValid() {
+			return fmt.Errorf(
+				"face %d would be %dx%d texels, over the engine limit of %d",
+				i, (extents.Width-1)*LightmapScale, (extents.Height-1)*LightmapScale, MaxSurfaceExtent,
+			)
+		}
+
+		lightmaps, err := bsp.FaceLightmaps(i)
+		if err != nil {
+			return err
+		}
+		if len(lightmaps) == 0 {
+			continue
+		}
+
+		oldExtents, err := bsp.FaceLightmapExtents(i)
+		if err != nil {
+			return err
+		}
+
+		var samples []byte
+		for _, lm := range lightmaps {
+			resampled := resampleLightmap(lm.Image, oldExtents, extents, scale)
+			for i := range len(resampled.Pix) / 4 {
+				samples = append(samples, resampled.Pix[i*4:i*4+bsp.lightingChannels()]...)
+			}
+		}
+		replaced[i] = samples
+	}
+
+	lighting, offsets, err := bsp.rebuildLighting(replaced)
+	if err != nil {
+		return err
+	}
+
+	bsp.Textures.Textures[index] = tex
+	bsp.TexInfo = texInfo
+	bsp.Lighting = lighting
+	for i := range bsp.Faces {
+		bsp.Faces[i].LightOffset = offsets[i]
+	}
+
+	return nil
+}
+
+// Lays out the lighting samples of all faces one after the other, faces in
+// replaced use the given samples instead of their current ones. Samples no
+// face uses anymore are dropped.
+// Returns the new lump and the new LightOffset of each face.
+func (bsp *BSP) rebuildLighting(replaced map[int][]byte) (RawLump, []int32, error) {
+	var (
+		lighting = make(RawLump, 0, len(bsp.Lighting))
+		offsets  = make([]int32, len(bsp.Faces))
+		moved    = map[int32]int32{} // old offset => new offset, faces can share samples
+	)
+	for i, face := range bsp.Faces {
+		if samples, ok := replaced[i]; ok {
+			offsets[i] = int32(len(lighting))
+			lighting = append(lighting, samples...)
+			continue
+		}
+
+		offsets[i] = face.LightOffset
+		if offset, ok := moved[face.LightOffset]; ok {
+			offsets[i] = offset
+			continue
+		}
+
+		lightmaps, err := bsp.FaceLightmaps(i)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(lightmaps) == 0 {
+			continue
+		}
+
+		var (
+			bounds = lightmaps[0].Image.Rect
+			size   = len(lightmaps) * bounds.Dx() * bounds.Dy() * bsp.lightingChannels()
+			start  = int(face.LightOffset)
+		)
+		offsets[i] = int32(len(lighting))
+		moved[face.LightOffset] = offsets[i]
+		lighting = append(lighting, bsp.Lighting[start:start+size]...)
+	}
+
+	return lighting, offsets, nil
+}
+
+// Samples a lightmap over new extents whose texture space was scaled.
+func resampleLightmap(src *image.RGBA, from, to LightmapExtents, scale [2]float32) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, to.Width, to.Height))
+	for y := range to.Height {
+		for x := range to.Width {
+			// Luxel position in the original lightmap.
+			sx := float32(to.Mins[0]+x)/scale[0] - float32(from.Mins[0])
+			sy := float32(to.Mins[1]+y)/scale[1] - float32(from.Mins[1])
+			dst.SetRGBA(x, y, bilinear(src, sx, sy))
+		}
+	}
+
+	return dst
+}
+
+func bilinear(img *image.RGBA, x, y float32) color.RGBA {
+	var (
+		maxX = float32(img.Rect.Dx() - 1)
+		maxY = float32(img.Rect.Dy() - 1)
+	)
+	x, y = min(max(x, 0), maxX), min(max(y, 0), maxY)
+
+	var (
+		x0, y0 = int(x), int(y)
+		x1, y1 = min(x0+1, int(maxX)), min(y0+1, int(maxY))
+		fx, fy = x - float32(x0), y - float32(y0)
+		a, b   = img.RGBAAt(x0, y0), img.RGBAAt(x1, y0)
+		c, d   = img.RGBAAt(x0, y1), img.RGBAAt(x1, y1)
+		lerp   = func(a, b, c, d uint8) uint8 {
+			top := float32(a)*(1-fx) + float32(b)*fx
+			bottom := float32(c)*(1-fx) + float32(d)*fx
+			return uint8(top*(1-fy) + bottom*fy + 0.5)
+		}
+	)
+
+	return color.RGBA{
+		lerp(a.R, b.R, c.R, d.R),
+		lerp(a.G, b.G, c.G, d.G),
+		lerp(a.B, b.B, c.B, d.B),
+		0xFF,
+	}
+}
+
+// Renames a texture. Textures that are not embedded will be searched in WADs
+// using their new name.
+// Renaming a frame of an animated (+0name, +Aname) or random (-0name) texture
+// renames all the frames of the sequence as the engine finds them by name.
+func (bsp *BSP) RenameTexture(oldName, newName string) error {
+	index, ok := bsp.Textures.Find(oldName)
+	if !ok {
+		return fmt.Errorf("texture %s does not exist", oldName)
+	}
+	oldName = bsp.Textures.Textures[index].Name.String()
+
+	if _, err := wad.NewTextureName(newName); err != nil {
+		return fmt.Errorf("invalid texture name '%s': %w", newName, err)
+	}
+	if !goldsrc.IsValidTextureName(strings.ToUpper(newName)) {
+		return fmt.Errorf("invalid texture name '%s': expected printable ASCII characters without spaces", newName)
+	}
+
+	// The engine and compilers decide how faces are drawn and lit by name.
+	if textureKind(oldName) != textureKind(newName) {
+		return fmt.Errorf(
+			"cannot rename %s to %s, sky, liquid, trigger, and transparent textures cannot be swapped with other kinds",
+			oldName, newName,
+		)
+	}
+
+	renames := map[int]string{index: newName}
+	oldKey, oldInSequence := textureSequence(oldName)
+	_, newInSequence := textureSequence(newName)
+	if oldInSequence != newInSequence || (oldInSequence && !strings.EqualFold(oldName[:2], newName[:2])) {
+		return fmt.Errorf(
+			"cannot rename %s to %s, frames of animated and random textures must keep their prefix",
+			oldName, newName,
+		)
+	}
+	if oldInSequence {
+		for i, tex := range bsp.Textures.Textures {
+			if key, ok := textureSequence(tex.Name.String()); ok && key == oldKey {
+				renames[i] = tex.Name.String()[:2] + newName[2:]
+			}
+		}
+	}
+
+	names := make(map[int]wad.TextureName, len(renames))
+	for i, v := range renames {
+		if other, ok := bsp.Textures.Find(v); ok {
+			if _, renamed := renames[other]; !renamed {
+				return fmt.Errorf("texture %s already exists", v)
+			}
+		}
+
+		name, err := wad.NewTextureName(v)
+		if err != nil {
+			return fmt.Errorf("invalid texture name '%s': %w", v, err)
+		}
+		names[i] = name
+	}
+
+	for i, name := range names {
+		bsp.Textures.Textures[i].Name = name
+	}
+
+	return nil
+}
+
+// Returns the kind of faces the engine and compilers make out of a texture
+// based on its name prefix, empty for regular textures.
+func textureKind(name string) string {
+	name = strings.ToLower(name)
+	switch {
+	case strings.HasPrefix(name, "sky"):
+		return "sky"
+	case strings.HasPrefix(name, "aaatrigger"):
+		return "trigger"
+	case strings.HasPrefix(name, "!"), strings.HasPrefix(name, "*"):
+		return "liquid"
+	case strings.HasPrefix(name, "{"):
+		return "transparent"
+	}
+
+	return ""
+}
+
+// Removes the texinfo records that are unused or duplicates of another one
+// and updates faces accordingly. Returns the number of removed records.
+func (bsp *BSP) CompactTexInfo() int {
+	used := make([]bool, len(bsp.TexInfo))
+	for _, face := range bsp.Faces {
+		if face.TexInfo >= 0 && int(face.TexInfo) < len(used) {
+			used[face.TexInfo] = true
+		}
+	}
+
+	var (
+		compacted = make(TexInfoLump, 0, len(bsp.TexInfo))
+		known     = make(map[TexInfo]int16, len(bsp.TexInfo))
+		remap     = make([]int16, len(bsp.TexInfo))
+	)
+	for i, texInfo := range bsp.TexInfo {
+		if !used[i] {
+			continue
+		}
+
+		index, ok := known[texInfo]
+		if !ok {
+			index = int16(len(compacted))
+			known[texInfo] = index
+			compacted = append(compacted, texInfo)
+		}
+		remap[i] = index
+	}
+
+	for i, face := range bsp.Faces {
+		if face.TexInfo >= 0 && int(face.TexInfo) < len(remap) {
+			bsp.Faces[i].TexInfo = remap[face.TexInfo]
+		}
+	}
+
+	removed := len(bsp.TexInfo) - len(compacted)
+	bsp.TexInfo = compacted
+
+	return removed
+}
+
+// Removes the textures that no texinfo references and updates texinfo
+// accordingly. Returns the names of the removed textures.
+// All frames of used animated (+0name) and random (-0name) textures are kept
+// as the engine finds them by name.
+func (bsp *BSP) RemoveUnusedTextures() []string {
+	var (
+		used     = make([]bool, len(bsp.Textures.Textures))
+		sequence = map[string]struct{}{}
+	)
+	for _, texInfo := range bsp.TexInfo {
+		if texInfo.MIPTex >= 0 && int(texInfo.MIPTex) < len(used) {
+			used[texInfo.MIPTex] = true
+			if key, ok := textureSequence(bsp.Textures.Textures[texInfo.MIPTex].Name.String()); ok {
+				sequence[key] = struct{}{}
+			}
+		}
+	}
+
+	var (
+		kept    = make([]wad.MIPTexture, 0, len(bsp.Textures.Textures))
+		remap   = make([]int32, len(bsp.Textures.Textures))
+		removed []string
+	)
+	for i, tex := range bsp.Textures.Textures {
+		key, ok := textureSequence(tex.Name.String())
+		if _, inSequence := sequence[key]; !used[i] && !(ok && inSequence) {
+			removed = append(removed, tex.Name.String())
+			continue
+		}
+
+		remap[i] = int32(len(kept))
+		kept = append(kept, tex)
+	}
+
+	for i, texInfo := range bsp.TexInfo {
+		if texInfo.MIPTex >= 0 && int(texInfo.MIPTex) < len(remap) {
+			bsp.TexInfo[i].MIPTex = remap[texInfo.MIPTex]
+		}
+	}
+	bsp.Textures.Textures = kept
+
+	return removed
+}
+
+// Returns the key shared by all frames of an animated or random texture.
+func textureSequence(name string) (string, bool) {
+	if len(name) < 2 || (name[0] != '+' && name[0] != '-') {
+		return "", false
+	}
+
+	return string(name[0]) + strings.ToLower(name[2:]), true
+}
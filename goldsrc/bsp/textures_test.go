@@ -0,0 +1,162 @@
+package bsp_test
+
+import (
+	"image/color"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/L-P/goldutil/goldsrc/bsp"
+	"github.com/L-P/goldutil/goldsrc/wad"
+	"github.com/L-P/goldutil/internal/bsptest"
+)
+
+func TestEmbedTextures(t *testing.T) {
+	b := bsptest.NewRoom(t)
+	require.Equal(t, []string{"wall"}, b.EmbedTextures(nil))
+
+	external := wad.New()
+	wall, err := wad.NewMIPTexture("WALL", 64, 64)
+	require.NoError(t, err)
+	require.NoError(t, wall.SetData(make([]byte, 64*64)))
+	require.NoError(t, external.AddTexture(wall))
+
+	require.Empty(t, b.EmbedTextures(wad.Collection{external}))
+	require.NoError(t, b.RemoveWADKey())
+
+	path, _ := bsptest.Write(t, b)
+	actual, err := bsp.LoadFromFile(path)
+	require.NoError(t, err)
+
+	require.True(t, actual.Textures.Textures[1].IsEmbedded())
+	require.Equal(t, "wall", actual.Textures.Textures[1].Name.String())
+	require.Equal(t, wall.MIPData, actual.Textures.Textures[1].MIPData)
+
+	names, err := actual.WADNames()
+	require.NoError(t, err)
+	require.Empty(t, names)
+}
+
+func TestReplaceTexture(t *testing.T) {
+	b := bsptest.NewRoom(t)
+
+	small, err := wad.NewMIPTexture("new", 32, 32)
+	require.NoError(t, err)
+	require.NoError(t, small.SetData(make([]byte, 32*32)))
+
+	var floorFace int
+	for i, face := range b.Faces {
+		if b.TexInfo[face.TexInfo].MIPTex == 0 {
+			floorFace = i
+		}
+	}
+	floorLightmaps, err := b.FaceLightmaps(floorFace)
+	require.NoError(t, err)
+
+	lightingSize := len(b.Lighting)
+	require.NoError(t, b.ReplaceTexture("WALL", small))
+	require.Equal(t, "wall", b.Textures.Textures[1].Name.String(), "name is kept")
+	require.True(t, b.Textures.Textures[1].IsEmbedded())
+
+	// Walls use half as many texels, and as many luxels.
+	require.Equal(t, bsp.Vec3f{Y: 0.5}, b.TexInfo[0].S.Vec)
+	require.Equal(t, bsp.Vec3f{Z: -0.5}, b.TexInfo[0].T.Vec)
+	ext, err := b.FaceLightmapExtents(1)
+	require.NoError(t, err)
+	require.Equal(t, bsp.LightmapExtents{Mins: [2]int{0, -8}, Width: 9, Height: 9}, ext)
+	require.Equal(t, lightingSize-4*17*17*3+4*9*9*3, len(b.Lighting), "lump is rebuilt")
+
+	lightmaps, err := b.FaceLightmaps(1)
+	require.NoError(t, err)
+	require.Len(t, lightmaps, 1)
+	require.Equal(t, color.RGBA{40, 40, 40, 0xFF}, lightmaps[0].Image.RGBAAt(4, 4))
+
+	actual, err := b.FaceLightmaps(floorFace)
+	require.NoError(t, err)
+	require.Equal(t, floorLightmaps, actual, "other faces keep their lightmaps")
+
+	// Going back to the original size does not leave unused samples behind.
+	original, err := wad.NewMIPTexture("new", 64, 64)
+	require.NoError(t, err)
+	require.NoError(t, original.SetData(make([]byte, 64*64)))
+	require.NoError(t, b.ReplaceTexture("WALL", original))
+	require.Equal(t, lightingSize, len(b.Lighting))
+	require.Equal(t, bsp.Vec3f{Y: 1}, b.TexInfo[0].S.Vec)
+
+	require.ErrorContains(t, b.ReplaceTexture("missing", small), "does not exist")
+}
+
+func TestReplaceTextureExtents(t *testing.T) {
+	b := bsptest.NewRoom(t)
+	var (
+		texInfo  = slices.Clone(b.TexInfo)
+		lighting = slices.Clone(b.Lighting)
+	)
+
+	// Walls would be 512 texels wide.
+	large, err := wad.NewMIPTexture("new", 128, 128)
+	require.NoError(t, err)
+	require.NoError(t, large.SetData(make([]byte, 128*128)))
+	require.ErrorContains(t, b.ReplaceTexture("WALL", large), "over the engine limit")
+
+	require.Equal(t, texInfo, b.TexInfo, "nothing is modified")
+	require.Equal(t, lighting, b.Lighting)
+	require.False(t, b.Textures.Textures[1].IsEmbedded())
+}
+
+func TestRenameTexture(t *testing.T) {
+	b := bsptest.NewRoom(t)
+
+	require.NoError(t, b.RenameTexture("WALL", "brick"))
+	require.Equal(t, "brick", b.Textures.Textures[1].Name.String())
+
+	require.ErrorContains(t, b.RenameTexture("brick", "floor"), "already exists")
+	require.ErrorContains(t, b.RenameTexture("brick", "sixteen_chars_xx"), "name is too long")
+	require.ErrorContains(t, b.RenameTexture("brick", "!water"), "cannot rename")
+	require.ErrorContains(t, b.RenameTexture("wall", "wall2"), "does not exist")
+
+	for _, name := range []string{"", "two words", "caf\u00e9", "tab\t"} {
+		require.ErrorContains(t, b.RenameTexture("brick", name), "invalid texture name", name)
+	}
+	require.Equal(t, "brick", b.Textures.Textures[1].Name.String())
+}
+
+func TestRenameTextureKinds(t *testing.T) {
+	b := bsptest.NewRoom(t)
+	b.Textures.Textures = append(b.Textures.Textures, newTextureHeader(t, "{fence"))
+
+	require.ErrorContains(t, b.RenameTexture("wall", "{grate"), "cannot rename")
+	require.ErrorContains(t, b.RenameTexture("{fence", "sky"), "cannot rename")
+	require.ErrorContains(t, b.RenameTexture("{fence", "fence"), "cannot rename")
+	require.NoError(t, b.RenameTexture("{fence", "{grate"))
+	require.Equal(t, "{grate", b.Textures.Textures[2].Name.String())
+}
+
+func TestRenameTextureSequence(t *testing.T) {
+	b := bsptest.NewRoom(t)
+	for _, name := range []string{"+0lava", "+1lava", "+Alava", "+0lavafall", "-0lava"} {
+		b.Textures.Textures = append(b.Textures.Textures, newTextureHeader(t, name))
+	}
+
+	require.ErrorContains(t, b.RenameTexture("+1lava", "+2fire"), "must keep their prefix")
+	require.ErrorContains(t, b.RenameTexture("+0lava", "fire"), "must keep their prefix")
+	require.ErrorContains(t, b.RenameTexture("wall", "+0wall"), "must keep their prefix")
+	require.ErrorContains(t, b.RenameTexture("+1lava", "+1lavafall"), "already exists")
+
+	require.NoError(t, b.RenameTexture("+1LAVA", "+1fire"))
+	var names []string
+	for _, tex := range b.Textures.Textures[2:] {
+		names = append(names, tex.Name.String())
+	}
+	require.Equal(t, []string{"+0fire", "+1fire", "+Afire", "+0lavafall", "-0lava"}, names)
+}
+
+func newTextureHeader(t *testing.T, name string) wad.MIPTexture {
+	t.Helper()
+
+	texName, err := wad.NewTextureName(name)
+	require.NoError(t, err)
+
+	return wad.MIPTexture{MIPTextureHeader: wad.MIPTextureHeader{Name: texName, Width: 16, Height: 16}}
+}
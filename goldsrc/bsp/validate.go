@@ -0,0 +1,125 @@
+package bsp
+
+import (
+	"fmt"
+)
+
+// An invalid reference from a lump entry to another lump.
+type ReferenceError struct {
+	Lump  LumpType
+	Index int // index of the offending entry in Lump
+	Msg   string
+}
+
+func (err ReferenceError) Error() string {
+	return fmt.Sprintf("%s[%d]: %s", err.Lump.String()[8:], err.Index, err.Msg)
+}
+
+// Checks the indexes between lumps and returns every invalid one.
+// Load only checks each lump on its own, a BSP can load fine and still crash
+// the engine.
+func (bsp *BSP) ValidateReferences() []ReferenceError {
+	var v refValidator
+
+	for i, face := range bsp.Faces {
+		v.index(LumpTypeFaces, i, "plane", int64(face.Plane), len(bsp.Planes))
+		v.span(LumpTypeFaces, i, "surfedges", int64(face.FirstEdge), int64(face.NumEdges), len(bsp.SurfEdges))
+		v.index(LumpTypeFaces, i, "texinfo", int64(face.TexInfo), len(bsp.TexInfo))
+		if face.NumEdges < 3 {
+			v.add(LumpTypeFaces, i, "has %d edges, at least 3 are required", face.NumEdges)
+		}
+		if face.LightOffset != -1 {
+			v.index(LumpTypeFaces, i, "light offset", int64(face.LightOffset), len(bsp.Lighting))
+		}
+	}
+
+	for i, surfEdge := range bsp.SurfEdges {
+		edge := int64(surfEdge)
+		if edge < 0 {
+			edge = -edge // negative surfedges use the edge in reverse
+		}
+		v.index(LumpTypeSurfEdges, i, "edge", edge, len(bsp.Edges))
+	}
+
+	for i, edge := range bsp.Edges {
+		v.index(LumpTypeEdges, i, "first vertex", int64(edge[0]), len(bsp.Vertices))
+		v.index(LumpTypeEdges, i, "second vertex", int64(edge[1]), len(bsp.Vertices))
+	}
+
+	for i, node := range bsp.Nodes {
+		v.index(LumpTypeNodes, i, "plane", int64(node.Plane), len(bsp.Planes))
+		v.span(LumpTypeNodes, i, "faces", int64(node.FirstFace), int64(node.NumFaces), len(bsp.Faces))
+		for side, child := range node.Children {
+			if child >= 0 {
+				v.index(LumpTypeNodes, i, fmt.Sprintf("child %d node", side), int64(child), len(bsp.Nodes))
+			} else {
+				v.index(LumpTypeNodes, i, fmt.Sprintf("child %d leaf", side), -(int64(child) + 1), len(bsp.Leaves))
+			}
+		}
+	}
+
+	for i, leaf := range bsp.Leaves {
+		v.span(LumpTypeLeaves, i, "marksurfaces", int64(leaf.FirstMarkSurface), int64(leaf.NumMarkSurfaces), len(bsp.MarkSurfaces))
+		if leaf.VisOffset != -1 {
+			v.index(LumpTypeLeaves, i, "vis offset", int64(leaf.VisOffset), len(bsp.Visibility))
+		}
+	}
+
+	for i, face := range bsp.MarkSurfaces {
+		v.index(LumpTypeMarkSurfaces, i, "face", int64(face), len(bsp.Faces))
+	}
+
+	for i, node := range bsp.ClipNodes {
+		v.index(LumpTypeClipNodes, i, "plane", int64(node.Plane), len(bsp.Planes))
+		for side, child := range node.Children {
+			if child >= 0 {
+				v.index(LumpTypeClipNodes, i, fmt.Sprintf("child %d clipnode", side), int64(child), len(bsp.ClipNodes))
+			} else if Contents(child) < ContentsTranslucent {
+				v.add(LumpTypeClipNodes, i, "child %d has invalid contents %d", side, child)
+			}
+		}
+	}
+
+	for i, model := range bsp.Models {
+		v.index(LumpTypeModels, i, "hull 0 headnode", int64(model.HeadNodes[0]), len(bsp.Nodes))
+		for hull := 1; hull < MaxMapHulls; hull++ {
+			// Negative headnodes are contents, used by models without clipping.
+			if head := model.HeadNodes[hull]; head >= 0 {
+				v.index(LumpTypeModels, i, fmt.Sprintf("hull %d headnode", hull), int64(head), len(bsp.ClipNodes))
+			}
+		}
+		v.span(LumpTypeModels, i, "faces", int64(model.FirstFace), int64(model.NumFaces), len(bsp.Faces))
+	}
+
+	for i, texInfo := range bsp.TexInfo {
+		v.index(LumpTypeTexInfo, i, "miptex", int64(texInfo.MIPTex), len(bsp.Textures.Textures))
+	}
+
+	return v.errs
+}
+
+type refValidator struct {
+	errs []ReferenceError
+}
+
+func (v *refValidator) add(lump LumpType, index int, format string, args ...any) {
+	v.errs = append(v.errs, ReferenceError{
+		Lump:  lump,
+		Index: index,
+		Msg:   fmt.Sprintf(format, args...),
+	})
+}
+
+// Checks a single index in a lump of the given length.
+func (v *refValidator) index(lump LumpType, index int, desc string, value int64, length int) {
+	if value < 0 || value >= int64(length) {
+		v.add(lump, index, "%s %d is out of bounds [0;%d)", desc, value, length)
+	}
+}
+
+// Checks a [first;first+count) range in a lump of the given length.
+func (v *refValidator) span(lump LumpType, index int, desc string, first, count int64, length int) {
+	if first < 0 || count < 0 || first+count > int64(length) {
+		v.add(lump, index, "%s [%d;%d) are out of bounds [0;%d)", desc, first, first+count, length)
+	}
+}
@@ -0,0 +1,43 @@
+package bsp_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/L-P/goldutil/goldsrc/bsp"
+	"github.com/L-P/goldutil/internal/bsptest"
+)
+
+func TestValidateReferences(t *testing.T) {
+	b := bsptest.NewRoom(t)
+	require.Empty(t, b.ValidateReferences())
+
+	b.Faces[2].FirstEdge = int32(len(b.SurfEdges)) - 1
+	b.SurfEdges[0] = -int32(len(b.Edges))
+	b.Edges[1][1] = uint16(len(b.Vertices))
+	b.Nodes[0].Children[1] = -int16(len(b.Leaves)) - 1
+	b.MarkSurfaces[3] = uint16(len(b.Faces))
+	b.Models[0].HeadNodes[2] = int32(len(b.ClipNodes))
+	b.TexInfo[1].MIPTex = -1
+
+	var actual []string
+	for _, err := range b.ValidateReferences() {
+		actual = append(actual, err.Error())
+	}
+
+	require.Equal(t, []string{
+		"Faces[2]: surfedges [23;27) are out of bounds [0;24)",
+		"SurfEdges[0]: edge 25 is out of bounds [0;25)",
+		"Edges[1]: second vertex 8 is out of bounds [0;8)",
+		"Nodes[0]: child 1 leaf 2 is out of bounds [0;2)",
+		"MarkSurfaces[3]: face 6 is out of bounds [0;6)",
+		"Models[0]: hull 2 headnode 18 is out of bounds [0;18)",
+		"TexInfo[1]: miptex -1 is out of bounds [0;2)",
+	}, actual)
+}
+
+func TestReferenceError(t *testing.T) {
+	err := bsp.ReferenceError{Lump: bsp.LumpTypeLeaves, Index: 3, Msg: "broken"}
+	require.Equal(t, "Leaves[3]: broken", err.Error())
+}
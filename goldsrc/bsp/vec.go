@@ -0,0 +1,52 @@
+package bsp
+
+import "fmt"
+
+type Vec3f struct {
+	X, Y, Z float32
+}
+
+func (vec Vec3f) String() string { // .map-compatible
+	return fmt.Sprintf("%f %f %f", vec.X, vec.Y, vec.Z)
+}
+
+// Short integer vector used for bounding boxes in nodes and leaves.
+type Vec3s [3]int16
+
+func (vec Vec3s) Vec3f() Vec3f {
+	return Vec3f{float32(vec[0]), float32(vec[1]), float32(vec[2])}
+}
+
+// Parses a .map-style "x y z" vector.
+func ParseVec3f(str string) (Vec3f, error) {
+	var vec Vec3f
+	if _, err := fmt.Sscanf(str, "%g %g %g", &vec.X, &vec.Y, &vec.Z); err != nil {
+		return Vec3f{}, fmt.Errorf("unable to parse vector '%s': %w", str, err)
+	}
+
+	return vec, nil
+}
+
+func (vec Vec3f) Add(other Vec3f) Vec3f {
+	return Vec3f{vec.X + other.X, vec.Y + other.Y, vec.Z + other.Z}
+}
+
+func (vec Vec3f) Sub(other Vec3f) Vec3f {
+	return Vec3f{vec.X - other.X, vec.Y - other.Y, vec.Z - other.Z}
+}
+
+func (vec Vec3f) Scale(f float32) Vec3f {
+	return Vec3f{vec.X * f, vec.Y * f, vec.Z * f}
+}
+
+func (vec Vec3f) Dot(other Vec3f) float32 {
+	return vec.X*other.X + vec.Y*other.Y + vec.Z*other.Z
+}
+
+func (vec Vec3f) Cross(other Vec3f) Vec3f {
+	return Vec3f{
+		vec.Y*other.Z - vec.Z*other.Y,
+		vec.Z*other.X - vec.X*other.Z,
+		vec.X*other.Y - vec.Y*other.X,
+	}
+}
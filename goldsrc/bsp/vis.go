@@ -0,0 +1,93 @@
+package bsp
+
+import "fmt"
+
+// Returns the number of leaves covered by the PVS, leaf 0 excluded.
+func (bsp *BSP) NumVisLeaves() int {
+	if len(bsp.Models) == 0 {
+		return 0
+	}
+
+	return int(bsp.Models[0].VisLeafs)
+}
+
+// Returns the decompressed PVS of a leaf, bit i is set when leaf i+1 is
+// visible. Leaves without visibility data (leaf 0, maps compiled without vis)
+// see everything.
+func (bsp *BSP) LeafPVS(leaf int) ([]byte, error) {
+	if leaf < 0 || leaf >= len(bsp.Leaves) {
+		return nil, fmt.Errorf("leaf %d does not exist", leaf)
+	}
+
+	var (
+		row    = (bsp.NumVisLeaves() + 7) / 8
+		ret    = make([]byte, 0, row)
+		offset = int(bsp.Leaves[leaf].VisOffset)
+	)
+	if leaf == 0 || offset < 0 || len(bsp.Visibility) == 0 {
+		for range row {
+			ret = append(ret, 0xFF)
+		}
+
+		return ret, nil
+	}
+
+	// Runs of zero bytes are stored as a 0 followed by the run length.
+	for len(ret) < row {
+		if offset >= len(bsp.Visibility) {
+			return nil, fmt.Errorf("leaf %d: PVS is out of the visibility lump bounds", leaf)
+		}
+
+		if v := bsp.Visibility[offset]; v != 0 {
+			ret = append(ret, v)
+			offset++
+			continue
+		}
+
+		if offset+1 >= len(bsp.Visibility) {
+			return nil, fmt.Errorf("leaf %d: PVS is out of the visibility lump bounds", leaf)
+		}
+
+		for range min(int(bsp.Visibility[offset+1]), row-len(ret)) {
+			ret = append(ret, 0)
+		}
+		offset += 2
+	}
+
+	return ret, nil
+}
+
+// Returns whether leaf b is in the PVS of leaf a.
+func (bsp *BSP) LeafVisible(a, b int) (bool, error) {
+	if b < 0 || b >= len(bsp.Leaves) {
+		return false, fmt.Errorf("leaf %d does not exist", b)
+	}
+
+	pvs, err := bsp.LeafPVS(a)
+	if err != nil {
+		return false, err
+	}
+
+	if b == 0 || b > bsp.NumVisLeaves() {
+		return false, nil
+	}
+
+	return pvs[(b-1)/8]&(1<<((b-1)%8)) != 0, nil
+}
+
+// Returns the leaves in the PVS of the given leaf.
+func (bsp *BSP) VisibleLeaves(leaf int) ([]int, error) {
+	pvs, err := bsp.LeafPVS(leaf)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []int
+	for i := range bsp.NumVisLeaves() {
+		if pvs[i/8]&(1<<(i%8)) != 0 {
+			ret = append(ret, i+1)
+		}
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,65 @@
+package bsp_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/L-P/goldutil/goldsrc/bsp"
+	"github.com/L-P/goldutil/internal/bsptest"
+)
+
+func TestVisibleLeaves(t *testing.T) {
+	b := bsptest.NewRoom(t)
+
+	// 19 leaves make a 3 bytes PVS row.
+	b.Models[0].VisLeafs = 19
+	for len(b.Leaves) <= 19 {
+		b.Leaves = append(b.Leaves, bsp.Leaf{Contents: -1, VisOffset: -1})
+	}
+	b.Visibility = bsp.RawLump{
+		0x01, 0x00, 0x02, // leaf 1, zero run
+		0xFF, 0xFF, 0x07, // leaf 2, everything
+		0x00, 0x01, 0x00, 0x01, 0x04, // leaf 3, two single zero runs
+		0x00, // leaf 5, truncated
+	}
+	b.Leaves[1].VisOffset = 0
+	b.Leaves[2].VisOffset = 3
+	b.Leaves[3].VisOffset = 6
+	b.Leaves[5].VisOffset = 11
+
+	var all = make([]int, 19)
+	for i := range all {
+		all[i] = i + 1
+	}
+
+	for leaf, expected := range map[int][]int{
+		0: all, // solid, everything is visible
+		1: {1},
+		2: all,
+		3: {19},
+		4: all, // no vis data
+	} {
+		actual, err := b.VisibleLeaves(leaf)
+		require.NoError(t, err)
+		require.Equal(t, expected, actual, "leaf %d", leaf)
+	}
+
+	_, err := b.VisibleLeaves(5)
+	require.ErrorContains(t, err, "out of the visibility lump bounds")
+
+	visible, err := b.LeafVisible(3, 19)
+	require.NoError(t, err)
+	require.True(t, visible)
+
+	visible, err = b.LeafVisible(3, 18)
+	require.NoError(t, err)
+	require.False(t, visible)
+
+	visible, err = b.LeafVisible(2, 0)
+	require.NoError(t, err)
+	require.False(t, visible, "leaf 0 is never visible")
+
+	_, err = b.LeafVisible(1, len(b.Leaves))
+	require.Error(t, err)
+}
@@ -0,0 +1,50 @@
+package bsp
+
+import "fmt"
+
+// Estimates the worst-case world polygon count (r_speeds wpoly) seen from
+// each leaf: every face marked by a leaf in the PVS, counted once.
+// Frustum and backface culling are not accounted for.
+// The returned slice is indexed by leaf, leaf 0 is solid and always 0.
+func (bsp *BSP) WPoly() ([]int, error) {
+	var (
+		numLeaves = bsp.NumVisLeaves()
+		ret       = make([]int, numLeaves+1)
+		seen      = make([]int, len(bsp.Faces)) // last leaf that counted the face
+	)
+	for i := range seen {
+		seen[i] = -1
+	}
+
+	for leaf := 1; leaf <= numLeaves; leaf++ {
+		visible, err := bsp.VisibleLeaves(leaf)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, other := range visible {
+			if other >= len(bsp.Leaves) {
+				return nil, fmt.Errorf("leaf %d: visible leaf %d does not exist", leaf, other)
+			}
+
+			first := int(bsp.Leaves[other].FirstMarkSurface)
+			last := first + int(bsp.Leaves[other].NumMarkSurfaces)
+			if last > len(bsp.MarkSurfaces) {
+				return nil, fmt.Errorf("leaf %d: marksurfaces are out of bounds", other)
+			}
+
+			for _, face := range bsp.MarkSurfaces[first:last] {
+				if int(face) >= len(bsp.Faces) {
+					return nil, fmt.Errorf("leaf %d: face %d does not exist", other, face)
+				}
+
+				if seen[face] != leaf {
+					seen[face] = leaf
+					ret[leaf]++
+				}
+			}
+		}
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,28 @@
+package bsp_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/L-P/goldutil/goldsrc/bsp"
+	"github.com/L-P/goldutil/internal/bsptest"
+)
+
+func TestWPoly(t *testing.T) {
+	b := bsptest.NewRoom(t)
+
+	// Add a second leaf seen from the first one sharing two of its faces.
+	b.Models[0].VisLeafs = 2
+	b.MarkSurfaces = append(b.MarkSurfaces, 0, 1)
+	b.Leaves = append(b.Leaves, bsp.Leaf{Contents: -1, VisOffset: 1, FirstMarkSurface: 6, NumMarkSurfaces: 2})
+	b.Visibility = bsp.RawLump{0x03, 0x02}
+
+	wpoly, err := b.WPoly()
+	require.NoError(t, err)
+	require.Equal(t, []int{0, 6, 2}, wpoly)
+
+	b.Leaves[2].NumMarkSurfaces = 3
+	_, err = b.WPoly()
+	require.ErrorContains(t, err, "marksurfaces are out of bounds")
+}
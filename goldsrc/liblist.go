@@ -0,0 +1,260 @@
+package goldsrc
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// A liblist.gam file. Lines are kept as read so comments and unknown keys are
+// written back verbatim.
+type Liblist struct {
+	lines []liblistLine
+}
+
+type liblistLine struct {
+	raw        string // empty for added lines
+	key, value string // key is empty for comments and blank lines
+	start, end int    // position of the value in raw, including its quotes
+}
+
+func NewLiblistFromReader(r io.Reader) (*Liblist, error) {
+	var (
+		ret        Liblist
+		scanner    = bufio.NewScanner(r)
+		lineNumber int
+	)
+
+	for scanner.Scan() {
+		lineNumber++
+		raw := scanner.Text()
+		line := strings.TrimSpace(raw)
+		if line == "" || strings.HasPrefix(line, "//") {
+			ret.lines = append(ret.lines, liblistLine{raw: raw})
+			continue
+		}
+
+		parsed, ok := parseLiblistLine(raw)
+		if !ok {
+			return nil, ParseError{"expected a key and a value", lineNumber, raw}
+		}
+
+		ret.lines = append(ret.lines, parsed)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read liblist: %w", err)
+	}
+
+	return &ret, nil
+}
+
+func NewLiblistFromFile(path string) (*Liblist, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open liblist: %w", err)
+	}
+	defer f.Close() //nolint:errcheck // readonly
+
+	return NewLiblistFromReader(f)
+}
+
+// Parses `key "value"`, the engine also accepts unquoted values. Anything
+// after the value, eg. a comment, is kept in raw.
+func parseLiblistLine(raw string) (liblistLine, bool) {
+	var (
+		line   = strings.TrimSpace(raw)
+		offset = strings.Index(raw, line)
+	)
+
+	i := strings.IndexAny(line, " \t")
+	if i < 0 {
+		return liblistLine{}, false
+	}
+
+	var (
+		key   = line[:i]
+		rest  = strings.TrimLeft(line[i:], " \t")
+		start = offset + len(line) - len(rest)
+	)
+	if value, ok := strings.CutPrefix(rest, `"`); ok {
+		end := strings.IndexByte(value, '"')
+		if end < 0 {
+			return liblistLine{}, false
+		}
+
+		return liblistLine{raw: raw, key: key, value: value[:end], start: start, end: start + end + 2}, true
+	}
+
+	value := strings.Fields(rest)[0]
+
+	return liblistLine{raw: raw, key: key, value: value, start: start, end: start + len(value)}, true
+}
+
+// Returns the value of the last occurrence of key, keys are case-insensitive.
+func (l *Liblist) Get(key string) (string, bool) {
+	for i := len(l.lines) - 1; i >= 0; i-- {
+		if strings.EqualFold(l.lines[i].key, key) {
+			return l.lines[i].value, true
+		}
+	}
+
+	return "", false
+}
+
+// Replaces the value of the last occurrence of key, keeping the rest of its
+// line, or appends it.
+func (l *Liblist) Set(key, value string) {
+	for i := len(l.lines) - 1; i >= 0; i-- {
+		line := &l.lines[i]
+		if !strings.EqualFold(line.key, key) {
+			continue
+		}
+
+		quoted := `"` + value + `"`
+		line.raw = line.raw[:line.start] + quoted + line.raw[line.end:]
+		line.value, line.end = value, line.start+len(quoted)
+
+		return
+	}
+
+	l.lines = append(l.lines, liblistLine{key: key, value: value})
+}
+
+func (l *Liblist) Write(w io.Writer) error {
+	var b strings.Builder
+	for _, v := range l.lines {
+		if v.raw != "" || v.key == "" {
+			b.WriteString(v.raw + "\n")
+			continue
+		}
+
+		fmt.Fprintf(&b, "%s \"%s\"\n", v.key, v.value)
+	}
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("unable to write liblist: %w", err)
+	}
+
+	return nil
+}
+
+// Keys read by the engine and the validation of their value.
+var liblistKeys = map[string]func(string) error{
+	"game":          validateNotEmpty,
+	"startmap":      validateMapName,
+	"trainmap":      validateMapName,
+	"gamedll":       validateDLLPath(".dll"),
+	"gamedll_linux": validateDLLPath(".so"),
+	"gamedll_osx":   validateDLLPath(".dylib"),
+	"fallback_dir":  validateDirName,
+	"type":          validateOneOf("", "singleplayer_only", "multiplayer_only"),
+	"cldll":         validateOneOf("0", "1"),
+	"svonly":        validateOneOf("0", "1"),
+	"secure":        validateOneOf("0", "1"),
+	"nomodels":      validateOneOf("0", "1"),
+	"nohimodel":     validateOneOf("0", "1"),
+	"hlversion":     validateNotEmpty,
+	"version":       validateNotEmpty,
+	"size":          validateInt,
+	"url_info":      nil,
+	"url_dl":        nil,
+	"icon":          nil,
+	"mpentity":      nil,
+	"fallback_maps": validateOneOf("0", "1"),
+}
+
+// Returns the issues with the values of known keys, unknown keys are
+// allowed as mods may read their own.
+func (l *Liblist) Validate() []error {
+	var (
+		errs []error
+		seen = map[string]bool{}
+	)
+
+	for _, v := range l.lines {
+		if v.key == "" {
+			continue
+		}
+
+		key := strings.ToLower(v.key)
+		if seen[key] {
+			errs = append(errs, fmt.Errorf("%s: key is set multiple times", v.key))
+		}
+		seen[key] = true
+
+		if validate := liblistKeys[key]; validate != nil {
+			if err := validate(v.value); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", v.key, err))
+			}
+		}
+	}
+
+	if !seen["game"] {
+		errs = append(errs, errors.New("game: key is missing"))
+	}
+
+	return errs
+}
+
+func validateNotEmpty(value string) error {
+	if value == "" {
+		return errors.New("value is empty")
+	}
+
+	return nil
+}
+
+func validateInt(value string) error {
+	if _, err := strconv.Atoi(value); err != nil {
+		return fmt.Errorf("'%s' is not an integer", value)
+	}
+
+	return nil
+}
+
+func validateMapName(value string) error {
+	if value == "" || strings.ContainsAny(value, `/\ `) || strings.HasSuffix(strings.ToLower(value), ".bsp") {
+		return fmt.Errorf("'%s' is not a map name, expected the BSP name without directory nor extension", value)
+	}
+
+	return nil
+}
+
+func validateDirName(value string) error {
+	if value == "" || strings.ContainsAny(value, `/\`) || value == "." || value == ".." {
+		return fmt.Errorf("'%s' is not a directory name", value)
+	}
+
+	return nil
+}
+
+func validateDLLPath(ext string) func(string) error {
+	return func(value string) error {
+		if !strings.HasSuffix(strings.ToLower(value), ext) {
+			return fmt.Errorf("'%s' does not end with %s", value, ext)
+		}
+
+		if strings.HasPrefix(value, "/") || strings.Contains(value, "..") {
+			return fmt.Errorf("'%s' is not relative to the mod directory", value)
+		}
+
+		return nil
+	}
+}
+
+func validateOneOf(values ...string) func(string) error {
+	return func(value string) error {
+		for _, v := range values {
+			if strings.EqualFold(v, value) {
+				return nil
+			}
+		}
+
+		return fmt.Errorf("'%s' is not one of %q", value, values)
+	}
+}
@@ -0,0 +1,82 @@
+package goldsrc_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/L-P/goldutil/goldsrc"
+)
+
+func TestLiblistRoundTrip(t *testing.T) {
+	input := `// Some comment.
+game "My Mod"
+startmap "intro" // first map
+gamedll "dlls\mymod.dll"
+gamedll_linux "dlls/mymod.so"
+type singleplayer_only
+
+custom_key "kept"
+`
+
+	liblist, err := goldsrc.NewLiblistFromReader(strings.NewReader(input))
+	require.NoError(t, err)
+	require.Empty(t, liblist.Validate())
+
+	v, ok := liblist.Get("GAMEDLL")
+	require.True(t, ok)
+	require.Equal(t, `dlls\mymod.dll`, v)
+
+	v, ok = liblist.Get("type")
+	require.True(t, ok)
+	require.Equal(t, "singleplayer_only", v)
+
+	var b strings.Builder
+	require.NoError(t, liblist.Write(&b))
+	require.Equal(t, input, b.String(), "unmodified liblists are written verbatim")
+
+	liblist.Set("startmap", "c0a0")
+	liblist.Set("trainmap", "t0a0")
+	liblist.Set("type", "multiplayer_only")
+	b.Reset()
+	require.NoError(t, liblist.Write(&b))
+	expected := strings.NewReplacer(
+		`startmap "intro" // first map`, `startmap "c0a0" // first map`,
+		`type singleplayer_only`, `type "multiplayer_only"`,
+	).Replace(input) + `trainmap "t0a0"` + "\n"
+	require.Equal(t, expected, b.String())
+
+	v, ok = liblist.Get("startmap")
+	require.True(t, ok)
+	require.Equal(t, "c0a0", v)
+}
+
+func TestLiblistValidate(t *testing.T) {
+	input := `game ""
+startmap "maps/intro.bsp"
+gamedll "dlls/mymod.so"
+type "coop"
+cldll "yes"
+cldll "1"
+`
+
+	liblist, err := goldsrc.NewLiblistFromReader(strings.NewReader(input))
+	require.NoError(t, err)
+
+	var errs []string
+	for _, err := range liblist.Validate() {
+		errs = append(errs, err.Error())
+	}
+	require.Equal(t, []string{
+		"game: value is empty",
+		"startmap: 'maps/intro.bsp' is not a map name, expected the BSP name without directory nor extension",
+		"gamedll: 'dlls/mymod.so' does not end with .dll",
+		`type: 'coop' is not one of ["" "singleplayer_only" "multiplayer_only"]`,
+		`cldll: 'yes' is not one of ["0" "1"]`,
+		"cldll: key is set multiple times",
+	}, errs)
+
+	_, err = goldsrc.NewLiblistFromReader(strings.NewReader("game \"unterminated\n"))
+	require.ErrorContains(t, err, "line #1")
+}
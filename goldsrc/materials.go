@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"strings"
 	"unicode"
@@ -71,6 +72,18 @@ func LoadMaterialsFromFile(path string) (Materials, error) {
 	return LoadMaterials(f)
 }
 
+// Reads a materials file from a filesystem, eg. "sound/materials.txt" from a
+// mod filesystem (see modfs).
+func LoadMaterialsFromFS(fsys fs.FS, name string) (Materials, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open materials file: %w", err)
+	}
+	defer f.Close() //nolint:errcheck // readonly
+
+	return LoadMaterials(f)
+}
+
 func LoadMaterials(r io.Reader) (Materials, error) {
 	var (
 		mats    = Materials(make(map[string]MaterialType))
@@ -0,0 +1,384 @@
+// Package modfs implements the layered filesystem the engine uses to find the
+// files of a mod.
+package modfs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/L-P/goldutil/goldsrc/pak"
+)
+
+// Directories searched before and after the base mod directory.
+var (
+	layersBefore = []string{"_addon", "_hd"}
+	layersAfter  = []string{"_downloads"}
+)
+
+// Returned by Path for files that are read from a PAK.
+var ErrInPAK = errors.New("file is inside a PAK")
+
+// FS is a read-only fs.FS where each file is read from the first directory
+// that has it, in the engine search order: the _addon, _hd, base, and
+// _downloads directories of the mod, then valve.
+// The pakN.pak files of a directory are searched before the directory
+// itself, the highest N first.
+// Names are matched case-insensitively like the engine does, exact matches
+// are preferred.
+type FS struct {
+	layers []layer
+}
+
+type layer struct {
+	fs.FS
+	path string   // directory or PAK file
+	pak  *pak.PAK // nil for directories
+}
+
+var (
+	_ fs.StatFS    = (*FS)(nil)
+	_ fs.ReadDirFS = (*FS)(nil)
+)
+
+// Creates the filesystem of the mod at moddir, moddir can be any of the
+// layers (eg. valve_addon).
+func New(moddir string) (*FS, error) {
+	moddir, err := filepath.Abs(moddir)
+	if err != nil {
+		return nil, fmt.Errorf("unable to obtain absolute mod path: %w", err)
+	}
+
+	if stat, err := os.Stat(moddir); err != nil {
+		return nil, fmt.Errorf("unable to use mod directory: %w", err)
+	} else if !stat.IsDir() {
+		return nil, fmt.Errorf("'%s' is not a directory", moddir)
+	}
+
+	var (
+		root = filepath.Dir(moddir)
+		name = filepath.Base(moddir)
+	)
+	for _, suffix := range slices.Concat(layersBefore, layersAfter) {
+		if v, ok := strings.CutSuffix(name, suffix); ok {
+			name = v
+			break
+		}
+	}
+
+	var names []string
+	for _, suffix := range layersBefore {
+		names = append(names, name+suffix)
+	}
+	names = append(names, name)
+	for _, suffix := range layersAfter {
+		names = append(names, name+suffix)
+	}
+	if name != "valve" {
+		names = append(names, "valve")
+	}
+
+	dirs := make([]string, 0, len(names))
+	for _, v := range names {
+		dirs = append(dirs, filepath.Join(root, v))
+	}
+
+	return newFromDirs(dirs)
+}
+
+// Creates the filesystem of the base game the mod at moddir runs on: the
+// valve directory and its PAKs. Players already have these files.
+func NewBase(moddir string) (*FS, error) {
+	moddir, err := filepath.Abs(moddir)
+	if err != nil {
+		return nil, fmt.Errorf("unable to obtain absolute mod path: %w", err)
+	}
+
+	valve := filepath.Join(filepath.Dir(moddir), "valve")
+	if stat, err := os.Stat(valve); err != nil {
+		return nil, fmt.Errorf("unable to use base game directory: %w", err)
+	} else if !stat.IsDir() {
+		return nil, fmt.Errorf("'%s' is not a directory", valve)
+	}
+
+	return newFromDirs([]string{valve})
+}
+
+// Mounts the existing directories and their PAKs, in order.
+func newFromDirs(dirs []string) (*FS, error) {
+	var ret FS
+	for _, dir := range dirs {
+		if stat, err := os.Stat(dir); err != nil || !stat.IsDir() {
+			continue
+		}
+
+		paks, err := findPAKs(dir)
+		if err != nil {
+			ret.Close() //nolint:errcheck // in another error path already
+			return nil, err
+		}
+
+		for _, path := range paks {
+			pak, err := pak.NewFromFile(path)
+			if err != nil {
+				ret.Close() //nolint:errcheck // in another error path already
+				return nil, fmt.Errorf("unable to open PAK at '%s': %w", path, err)
+			}
+
+			ret.layers = append(ret.layers, layer{FS: pak, path: path, pak: pak})
+		}
+
+		ret.layers = append(ret.layers, layer{FS: os.DirFS(dir), path: dir})
+	}
+
+	return &ret, nil
+}
+
+// Returns the paths of the pakN.pak files of a directory, highest N first.
+// Names are matched case-insensitively, eg. PAK0.PAK.
+func findPAKs(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list '%s': %w", dir, err)
+	}
+
+	paks := map[int]string{} // N => file name
+	for _, v := range entries {
+		name := strings.ToLower(v.Name())
+		if !strings.HasPrefix(name, "pak") || !strings.HasSuffix(name, ".pak") || v.IsDir() {
+			continue
+		}
+
+		n, err := strconv.Atoi(name[3 : len(name)-4])
+		if err != nil || n < 0 || fmt.Sprintf("pak%d.pak", n) != name {
+			continue
+		}
+
+		if _, ok := paks[n]; !ok {
+			paks[n] = v.Name()
+		}
+	}
+
+	numbers := slices.Sorted(maps.Keys(paks))
+	slices.Reverse(numbers)
+
+	ret := make([]string, 0, len(numbers))
+	for _, n := range numbers {
+		ret = append(ret, filepath.Join(dir, paks[n]))
+	}
+
+	return ret, nil
+}
+
+// Closes the mounted PAKs.
+func (fsys *FS) Close() error {
+	var errs []error
+	for _, v := range fsys.layers {
+		if v.pak != nil {
+			errs = append(errs, v.pak.Close())
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Returns the existing directories of the filesystem, in search order.
+func (fsys *FS) Dirs() []string {
+	var ret []string
+	for _, v := range fsys.layers {
+		if v.pak == nil {
+			ret = append(ret, v.path)
+		}
+	}
+
+	return ret
+}
+
+// Returns the mounted PAK files, in search order.
+func (fsys *FS) PAKs() []string {
+	var ret []string
+	for _, v := range fsys.layers {
+		if v.pak != nil {
+			ret = append(ret, v.path)
+		}
+	}
+
+	return ret
+}
+
+func (fsys *FS) Open(name string) (fs.File, error) {
+	i, resolved, err := fsys.find("open", name)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := fsys.layers[i].Open(resolved)
+	if err != nil {
+		return nil, err
+	}
+
+	stat, err := f.Stat()
+	if err != nil || !stat.IsDir() {
+		return f, err
+	}
+
+	entries, err := fsys.ReadDir(name)
+	if err != nil {
+		f.Close() //nolint:errcheck // in another error path already
+		return nil, err
+	}
+
+	return &dir{File: f, entries: entries}, nil
+}
+
+func (fsys *FS) Stat(name string) (fs.FileInfo, error) {
+	i, resolved, err := fsys.find("stat", name)
+	if err != nil {
+		return nil, err
+	}
+
+	return fs.Stat(fsys.layers[i].FS, resolved)
+}
+
+// Returns the path on disk of the file that would be read for name, files
+// read from a PAK have none and return ErrInPAK.
+func (fsys *FS) Path(name string) (string, error) {
+	i, resolved, err := fsys.find("path", name)
+	if err != nil {
+		return "", err
+	}
+
+	if fsys.layers[i].pak != nil {
+		return "", fmt.Errorf("%s is in '%s': %w", name, fsys.layers[i].path, ErrInPAK)
+	}
+
+	return filepath.Join(fsys.layers[i].path, filepath.FromSlash(resolved)), nil
+}
+
+// Merges the entries of the directory in all layers, entries from the first
+// layers hide the ones with the same case-insensitive name in the next
+// layers.
+func (fsys *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+
+	var (
+		ret   []fs.DirEntry
+		seen  = map[string]bool{}
+		found bool
+	)
+	for _, layer := range fsys.layers {
+		resolved, err := resolve(layer.FS, name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		entries, err := fs.ReadDir(layer.FS, resolved)
+		if err != nil {
+			return nil, err
+		}
+
+		found = true
+		for _, v := range entries {
+			if key := strings.ToLower(v.Name()); !seen[key] {
+				seen[key] = true
+				ret = append(ret, v)
+			}
+		}
+	}
+
+	if !found {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+
+	slices.SortFunc(ret, func(a, b fs.DirEntry) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+
+	return ret, nil
+}
+
+// Returns the index of the first layer containing name and the name of the
+// file in that layer.
+func (fsys *FS) find(op, name string) (int, string, error) {
+	if !fs.ValidPath(name) {
+		return 0, "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+
+	for i, layer := range fsys.layers {
+		resolved, err := resolve(layer.FS, name)
+		if err == nil {
+			return i, resolved, nil
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return 0, "", err
+		}
+	}
+
+	return 0, "", &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+}
+
+// Returns the name of the file matching name in a layer, ignoring case when
+// there is no exact match.
+func resolve(fsys fs.FS, name string) (string, error) {
+	_, err := fs.Stat(fsys, name)
+	if !errors.Is(err, fs.ErrNotExist) {
+		return name, err
+	}
+
+	var (
+		parts    = strings.Split(name, "/")
+		resolved = "."
+	)
+	for i, part := range parts {
+		entries, err := fs.ReadDir(fsys, resolved)
+		if err != nil {
+			return "", err
+		}
+
+		j := slices.IndexFunc(entries, func(v fs.DirEntry) bool {
+			return strings.EqualFold(v.Name(), part) && (v.IsDir() || i == len(parts)-1)
+		})
+		if j < 0 {
+			return "", &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+		}
+
+		resolved = path.Join(resolved, entries[j].Name())
+	}
+
+	return resolved, nil
+}
+
+// A directory opened from the first layer listing the entries of all layers.
+type dir struct {
+	fs.File
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *dir) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return rest, nil
+	}
+
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+
+	n = min(n, len(rest))
+	d.offset += n
+
+	return rest[:n], nil
+}
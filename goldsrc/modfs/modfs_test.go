@@ -0,0 +1,156 @@
+package modfs_test
+
+import (
+	"bytes"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/L-P/goldutil/goldsrc/modfs"
+	"github.com/L-P/goldutil/goldsrc/pak"
+)
+
+func TestFS(t *testing.T) {
+	root := t.TempDir()
+	for path, content := range map[string]string{
+		"valve/titles.txt":          "valve",
+		"valve/halflife.wad":        "valve",
+		"valve/sound/materials.txt": "valve",
+		"mod/titles.txt":            "mod",
+		"mod/maps/a.bsp":            "mod",
+		"mod_addon/titles.txt":      "addon",
+		"mod_addon/maps/b.bsp":      "addon",
+		"mod_downloads/maps/a.bsp":  "downloads",
+		"other/titles.txt":          "other",
+	} {
+		path = filepath.Join(root, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o700))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+
+	fsys, err := modfs.New(filepath.Join(root, "mod_addon"))
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		filepath.Join(root, "mod_addon"),
+		filepath.Join(root, "mod"),
+		filepath.Join(root, "mod_downloads"),
+		filepath.Join(root, "valve"),
+	}, fsys.Dirs())
+
+	for name, expected := range map[string]string{
+		"titles.txt":          "addon",
+		"maps/a.bsp":          "mod",
+		"maps/b.bsp":          "addon",
+		"sound/materials.txt": "valve",
+	} {
+		actual, err := fs.ReadFile(fsys, name)
+		require.NoError(t, err)
+		require.Equal(t, expected, string(actual), name)
+	}
+
+	_, err = fsys.Open("missing.txt")
+	require.ErrorIs(t, err, fs.ErrNotExist)
+
+	path, err := fsys.Path("halflife.wad")
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(root, "valve", "halflife.wad"), path)
+
+	bsps, err := fs.Glob(fsys, "maps/*.bsp")
+	require.NoError(t, err)
+	require.Equal(t, []string{"maps/a.bsp", "maps/b.bsp"}, bsps)
+
+	require.NoError(t, fstest.TestFS(fsys, "titles.txt", "maps/a.bsp", "maps/b.bsp", "halflife.wad"))
+
+	_, err = modfs.New(filepath.Join(root, "missing"))
+	require.Error(t, err)
+
+	base, err := modfs.NewBase(filepath.Join(root, "mod"))
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join(root, "valve")}, base.Dirs())
+}
+
+func TestFSPAK(t *testing.T) {
+	root := t.TempDir()
+	valve := filepath.Join(root, "valve")
+	require.NoError(t, os.MkdirAll(valve, 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(valve, "titles.txt"), []byte("valve"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(valve, "liblist.gam"), []byte("valve"), 0o600))
+
+	for name, files := range map[string]fstest.MapFS{
+		"pak0.pak": {"titles.txt": {Data: []byte("pak0")}, "liblist.gam": {Data: []byte("pak0")}},
+		"PAK1.PAK": {"titles.txt": {Data: []byte("pak1")}},
+	} {
+		var b bytes.Buffer
+		require.NoError(t, pak.Write(&b, files, slices.Sorted(maps.Keys(files))))
+		require.NoError(t, os.WriteFile(filepath.Join(valve, name), b.Bytes(), 0o600))
+	}
+
+	fsys, err := modfs.New(valve)
+	require.NoError(t, err)
+	defer fsys.Close() //nolint:errcheck // readonly
+
+	require.Equal(t, []string{filepath.Join(valve, "PAK1.PAK"), filepath.Join(valve, "pak0.pak")}, fsys.PAKs())
+
+	for name, expected := range map[string]string{
+		"titles.txt":  "pak1",
+		"liblist.gam": "pak0",
+	} {
+		actual, err := fs.ReadFile(fsys, name)
+		require.NoError(t, err)
+		require.Equal(t, expected, string(actual), name)
+	}
+
+	_, err = fsys.Path("titles.txt")
+	require.ErrorIs(t, err, modfs.ErrInPAK)
+}
+
+func TestFSCase(t *testing.T) {
+	root := t.TempDir()
+	for path, content := range map[string]string{
+		"valve/MODELS/Tree.mdl": "valve",
+		"valve/sound/a.wav":     "valve",
+		"mod/models/TREE.MDL":   "mod",
+		"mod/models/bush.mdl":   "mod",
+	} {
+		path = filepath.Join(root, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o700))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+
+	var b bytes.Buffer
+	files := fstest.MapFS{"sound/b.wav": {Data: []byte("pak0")}}
+	require.NoError(t, pak.Write(&b, files, []string{"sound/b.wav"}))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "valve", "pak0.pak"), b.Bytes(), 0o600))
+
+	fsys, err := modfs.New(filepath.Join(root, "mod"))
+	require.NoError(t, err)
+	defer fsys.Close() //nolint:errcheck // readonly
+
+	for name, expected := range map[string]string{
+		"models/tree.mdl": "mod",
+		"MODELS/BUSH.MDL": "mod",
+		"SOUND/A.WAV":     "valve",
+		"Sound/B.wav":     "pak0",
+	} {
+		actual, err := fs.ReadFile(fsys, name)
+		require.NoError(t, err, name)
+		require.Equal(t, expected, string(actual), name)
+	}
+
+	_, err = fs.Stat(fsys, "models/missing.mdl")
+	require.ErrorIs(t, err, fs.ErrNotExist)
+
+	path, err := fsys.Path("models/Bush.MDL")
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(root, "mod", "models", "bush.mdl"), path)
+
+	entries, err := fs.ReadDir(fsys, "Models")
+	require.NoError(t, err)
+	require.Len(t, entries, 2, "TREE.MDL from mod hides Tree.mdl from valve")
+}
@@ -0,0 +1,444 @@
+// Package modpack collects BSPs and the files they need from a mod
+// directory, ready for release.
+package modpack
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/L-P/goldutil/goldsrc"
+	"github.com/L-P/goldutil/goldsrc/bsp"
+	"github.com/L-P/goldutil/goldsrc/modfs"
+	"github.com/L-P/goldutil/goldsrc/wad"
+	"github.com/L-P/goldutil/internal/set"
+)
+
+const materialsPath = "sound/materials.txt"
+
+// Optional files next to a map, %s is the map name.
+var mapCompanions = []string{
+	"maps/%s.txt",
+	"maps/%s_detail.txt",
+	"overviews/%s.txt",
+	"overviews/%s.bmp",
+	"overviews/%s.tga",
+}
+
+// Packer holds the packed files in memory until they are written.
+type Packer struct {
+	mod, base *modfs.FS
+	wadName   string
+	wad       wad.WAD
+
+	files    map[string][]byte // mod-relative path => content
+	textures set.PresenceSet[string]
+	missing  []string
+}
+
+// Creates a packer reading files from mod, files identical in base are not
+// packed. Textures that are not in a base WAD are packed in a WAD named
+// wadName.
+func New(mod, base *modfs.FS, wadName string) *Packer {
+	return &Packer{
+		mod:      mod,
+		base:     base,
+		wadName:  wadName,
+		wad:      wad.New(),
+		files:    map[string][]byte{},
+		textures: set.NewPresenceSet[string](0),
+	}
+}
+
+// Returns the files and textures that could not be found, prefixed by the
+// path of the BSP that needs them.
+func (p *Packer) Missing() []string {
+	return slices.Clone(p.missing)
+}
+
+// Adds the BSP, its resources, and its .res file. The worldspawn "wad" key
+// of the packed BSP lists the packed WAD first, then the base game WADs.
+// Missing files and textures are recorded, see Missing.
+func (p *Packer) AddBSP(path string) error {
+	b, err := bsp.LoadFromFile(path)
+	if err != nil {
+		return fmt.Errorf("unable to load BSP at '%s': %w", path, err)
+	}
+
+	var (
+		name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		dest = "maps/" + name + ".bsp"
+	)
+	if _, ok := p.files[dest]; ok {
+		return fmt.Errorf("two BSPs are named %s", name)
+	}
+
+	wads, err := p.addTextures(path, b)
+	if err != nil {
+		return err
+	}
+
+	if err := setWADKey(b, wads); err != nil {
+		return fmt.Errorf("unable to write WAD list of '%s': %w", path, err)
+	}
+
+	resources, err := b.Resources()
+	if err != nil {
+		return fmt.Errorf("unable to list resources of '%s': %w", path, err)
+	}
+
+	var res []string
+	for _, v := range resources {
+		if v == p.wadName {
+			res = append(res, v)
+			continue
+		}
+
+		packed, err := p.addFile(path, v, true)
+		if err != nil {
+			return err
+		}
+		if packed {
+			res = append(res, v)
+		}
+
+		if err := p.addModelCompanions(path, v); err != nil {
+			return err
+		}
+	}
+
+	for _, v := range mapCompanions {
+		if _, err := p.addFile(path, fmt.Sprintf(v, name), false); err != nil {
+			return err
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := bsp.WriteRES(&buf, res); err != nil {
+		return err
+	}
+	p.files["maps/"+name+".res"] = buf.Bytes()
+
+	var data memFile
+	if err := b.Write(&data); err != nil {
+		return fmt.Errorf("unable to write BSP '%s': %w", path, err)
+	}
+	p.files[dest] = data.buf
+
+	return nil
+}
+
+// Studio models can have their textures in a T model and their sequences in
+// numbered models next to them.
+func (p *Packer) addModelCompanions(bspPath, path string) error {
+	stem, ok := strings.CutSuffix(path, ".mdl")
+	if !ok {
+		return nil
+	}
+
+	if _, err := p.addFile(bspPath, stem+"t.mdl", false); err != nil {
+		return err
+	}
+
+	for i := 1; ; i++ {
+		packed, err := p.addFile(bspPath, fmt.Sprintf("%s%02d.mdl", stem, i), false)
+		if err != nil || !packed {
+			return err
+		}
+	}
+}
+
+// Adds a file unless the base game has the same one, returns whether it was
+// packed. Missing required files are recorded for bspPath.
+func (p *Packer) addFile(bspPath, path string, required bool) (bool, error) {
+	if _, ok := p.files[path]; ok {
+		return true, nil
+	}
+
+	if !fs.ValidPath(path) {
+		p.missing = append(p.missing, fmt.Sprintf("%s: invalid path %s", bspPath, path))
+		return false, nil
+	}
+
+	data, err := fs.ReadFile(p.mod, path)
+	if errors.Is(err, fs.ErrNotExist) {
+		if required {
+			p.missing = append(p.missing, fmt.Sprintf("%s: missing file %s", bspPath, path))
+		}
+
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("unable to read '%s': %w", path, err)
+	}
+
+	if p.isBase(path, data) {
+		return false, nil
+	}
+
+	p.files[path] = data
+
+	return true, nil
+}
+
+func (p *Packer) isBase(path string, data []byte) bool {
+	baseData, err := fs.ReadFile(p.base, path)
+	return err == nil && bytes.Equal(data, baseData)
+}
+
+// Copies the textures the BSP reads from non-base WADs to the packed WAD,
+// returns the WADs the packed BSP needs.
+func (p *Packer) addTextures(bspPath string, b *bsp.BSP) ([]string, error) {
+	names, err := b.WADNames()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read WAD list of '%s': %w", bspPath, err)
+	}
+
+	var (
+		wads   = make([]wad.Collection, 0, len(names))
+		isBase = make([]bool, 0, len(names))
+	)
+	for _, name := range names {
+		if !fs.ValidPath(name) {
+			p.missing = append(p.missing, fmt.Sprintf("%s: invalid path %s", bspPath, name))
+			wads = append(wads, nil)
+			isBase = append(isBase, false)
+			continue
+		}
+
+		data, err := fs.ReadFile(p.mod, name)
+		if errors.Is(err, fs.ErrNotExist) {
+			p.missing = append(p.missing, fmt.Sprintf("%s: missing file %s", bspPath, name))
+			wads = append(wads, nil)
+			isBase = append(isBase, false)
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("unable to read '%s': %w", name, err)
+		}
+
+		w, err := wad.NewFromFS(p.mod, name)
+		if err != nil {
+			return nil, fmt.Errorf("unable to open WAD '%s': %w", name, err)
+		}
+
+		wads = append(wads, wad.Collection{w})
+		isBase = append(isBase, p.isBase(name, data))
+	}
+
+	var usesPacked bool
+textures:
+	for _, tex := range b.Textures.Textures {
+		texName := strings.ToUpper(tex.Name.String())
+		p.textures.Set(texName)
+		if tex.IsEmbedded() {
+			continue
+		}
+
+		for i, w := range wads {
+			mip, ok := w.GetTexture(texName)
+			if !ok {
+				continue
+			}
+
+			if !isBase[i] {
+				usesPacked = true
+				if _, ok := p.wad.GetTexture(texName); !ok {
+					if err := p.wad.AddTexture(mip); err != nil {
+						return nil, fmt.Errorf("unable to add texture to packed WAD: %w", err)
+					}
+				}
+			}
+
+			continue textures
+		}
+
+		p.missing = append(p.missing, fmt.Sprintf("%s: missing texture %s", bspPath, texName))
+	}
+
+	var ret []string
+	if usesPacked {
+		ret = append(ret, p.wadName)
+
+		var buf bytes.Buffer
+		if err := p.wad.Write(&buf); err != nil {
+			return nil, fmt.Errorf("unable to write packed WAD: %w", err)
+		}
+		p.files[p.wadName] = buf.Bytes()
+	}
+
+	for i, name := range names {
+		if isBase[i] {
+			ret = append(ret, name)
+		}
+	}
+
+	return ret, nil
+}
+
+func setWADKey(b *bsp.BSP, wads []string) error {
+	qm, err := b.LoadEntities()
+	if err != nil {
+		return err
+	}
+
+	worldspawn := qm.FindByKV("classname", "worldspawn")
+	if len(worldspawn) != 1 {
+		return errors.New("expected a single worldspawn")
+	}
+	worldspawn[0].Entity.KVs["wad"] = strings.Join(wads, ";")
+
+	return b.SetEntities(qm)
+}
+
+// Returns the materials of the textures used by the BSPs if the mod has its
+// own materials.txt, nil otherwise.
+func (p *Packer) materials() ([]byte, error) {
+	data, err := fs.ReadFile(p.mod, materialsPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("unable to read '%s': %w", materialsPath, err)
+	}
+
+	if p.isBase(materialsPath, data) {
+		return nil, nil
+	}
+
+	materials, err := goldsrc.LoadMaterials(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse '%s': %w", materialsPath, err)
+	}
+
+	var buf bytes.Buffer
+	for _, name := range slices.Sorted(maps.Keys(materials)) {
+		if p.textures.Has(strings.ToUpper(name)) {
+			fmt.Fprintf(&buf, "%c %s\n", materials[name], name)
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Writes the packed files to out, a ZIP archive if it ends with .zip or a
+// directory otherwise. out must not exist, nothing is written if anything is
+// missing. Returns the number of written files.
+func (p *Packer) Write(out string) (int, error) {
+	if len(p.missing) > 0 {
+		return 0, fmt.Errorf("found %d missing resources, nothing was written", len(p.missing))
+	}
+
+	files := maps.Clone(p.files)
+	materials, err := p.materials()
+	if err != nil {
+		return 0, err
+	}
+	if materials != nil {
+		files[materialsPath] = materials
+	}
+
+	if strings.EqualFold(filepath.Ext(out), ".zip") {
+		err = writeZIP(out, files)
+	} else {
+		err = writeDir(out, files)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return len(files), nil
+}
+
+func writeDir(dir string, files map[string][]byte) error {
+	if err := os.Mkdir(dir, 0750); err != nil {
+		return fmt.Errorf("unable to create output directory: %w", err)
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(files)) {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			return fmt.Errorf("unable to create directory for '%s': %w", name, err)
+		}
+
+		if err := os.WriteFile(path, files[name], 0600); err != nil {
+			return fmt.Errorf("unable to write '%s': %w", path, err)
+		}
+	}
+
+	return nil
+}
+
+func writeZIP(path string, files map[string][]byte) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("unable to open '%s' for writing: %w", path, err)
+	}
+
+	zw := zip.NewWriter(f)
+	for _, name := range slices.Sorted(maps.Keys(files)) {
+		w, err := zw.Create(name)
+		if err != nil {
+			f.Close() //nolint:errcheck // in another error path already
+			return fmt.Errorf("unable to add '%s' to archive: %w", name, err)
+		}
+
+		if _, err := w.Write(files[name]); err != nil {
+			f.Close() //nolint:errcheck // in another error path already
+			return fmt.Errorf("unable to write '%s' to archive: %w", name, err)
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		f.Close() //nolint:errcheck // in another error path already
+		return fmt.Errorf("unable to finalize archive: %w", err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("unable to finalize writing to '%s': %w", path, err)
+	}
+
+	return nil
+}
+
+// In-memory io.WriteSeeker for BSPs.
+type memFile struct {
+	buf []byte
+	pos int
+}
+
+func (f *memFile) Write(p []byte) (int, error) {
+	if end := f.pos + len(p); end > len(f.buf) {
+		f.buf = append(f.buf, make([]byte, end-len(f.buf))...)
+	}
+	n := copy(f.buf[f.pos:], p)
+	f.pos += n
+
+	return n, nil
+}
+
+func (f *memFile) Seek(offset int64, whence int) (int64, error) {
+	var pos int64
+	switch whence {
+	case io.SeekStart:
+		pos = offset
+	case io.SeekCurrent:
+		pos = int64(f.pos) + offset
+	case io.SeekEnd:
+		pos = int64(len(f.buf)) + offset
+	default:
+		return 0, errors.New("invalid whence")
+	}
+
+	if pos < 0 {
+		return 0, errors.New("negative position")
+	}
+	f.pos = int(pos)
+
+	return pos, nil
+}
@@ -0,0 +1,270 @@
+package modpack_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/L-P/goldutil/goldsrc/bsp"
+	"github.com/L-P/goldutil/goldsrc/modfs"
+	"github.com/L-P/goldutil/goldsrc/modpack"
+	"github.com/L-P/goldutil/goldsrc/wad"
+	"github.com/L-P/goldutil/internal/bsptest"
+)
+
+const entities = `{
+"classname" "worldspawn"
+"wad" "\half-life\valve\halflife.wad;test.wad"
+}
+{
+"classname" "env_model"
+"model" "models/tree.mdl"
+}
+{
+"classname" "ambient_generic"
+"message" "ambience/drips.wav"
+}
+`
+
+// Creates a valve and a mod directory in a temporary directory, the mod has
+// the room BSP in maps/room.bsp. Returns the path to the mod directory.
+func newTree(t *testing.T, files map[string][]byte) string {
+	t.Helper()
+
+	root := t.TempDir()
+	b := bsptest.NewRoom(t)
+	require.NoError(t, b.ImportEntities(strings.NewReader(entities)))
+	files["mod/maps/room.bsp"] = writeBSP(t, b)
+
+	for path, content := range files {
+		path = filepath.Join(root, filepath.FromSlash(path))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o700))
+		require.NoError(t, os.WriteFile(path, content, 0o600))
+	}
+
+	return filepath.Join(root, "mod")
+}
+
+func writeBSP(t *testing.T, b *bsp.BSP) []byte {
+	t.Helper()
+
+	_, data := bsptest.Write(t, b)
+
+	return data
+}
+
+func newWAD(t *testing.T, names ...string) []byte {
+	t.Helper()
+
+	w := wad.New()
+	for _, name := range names {
+		mip, err := wad.NewMIPTexture(name, 64, 64)
+		require.NoError(t, err)
+		require.NoError(t, mip.SetData(make([]byte, 64*64)))
+		require.NoError(t, w.AddTexture(mip))
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, w.Write(&buf))
+
+	return buf.Bytes()
+}
+
+func newPacker(t *testing.T, modDir, wadName string) *modpack.Packer {
+	t.Helper()
+
+	mod, err := modfs.New(modDir)
+	require.NoError(t, err)
+	t.Cleanup(func() { mod.Close() }) //nolint:errcheck // readonly
+
+	base, err := modfs.NewBase(modDir)
+	require.NoError(t, err)
+	t.Cleanup(func() { base.Close() }) //nolint:errcheck // readonly
+
+	p := modpack.New(mod, base, wadName)
+	require.NoError(t, p.AddBSP(filepath.Join(modDir, "maps", "room.bsp")))
+
+	return p
+}
+
+// Returns the files of a packed directory, slash-separated path => content.
+func readDir(t *testing.T, dir string) map[string][]byte {
+	t.Helper()
+
+	ret := map[string][]byte{}
+	require.NoError(t, fs.WalkDir(os.DirFS(dir), ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		data, err := fs.ReadFile(os.DirFS(dir), path)
+		ret[path] = data
+
+		return err
+	}))
+
+	return ret
+}
+
+func wadKey(t *testing.T, path string) string {
+	t.Helper()
+
+	b, err := bsp.LoadFromFile(path)
+	require.NoError(t, err)
+	qm, err := b.LoadEntities()
+	require.NoError(t, err)
+	worldspawn := qm.FindByKV("classname", "worldspawn")
+	require.Len(t, worldspawn, 1)
+
+	return worldspawn[0].Entity.KVs["wad"]
+}
+
+func TestPackBaseWAD(t *testing.T) {
+	var (
+		tree = newTree(t, map[string][]byte{
+			"valve/halflife.wad":             newWAD(t, "OTHER"),
+			"valve/test.wad":                 newWAD(t, "WALL"),
+			"valve/models/tree.mdl":          []byte("tree"),
+			"valve/sound/ambience/drips.wav": []byte("drips"),
+			"mod/models/tree.mdl":            []byte("tree"),
+			"mod/sound/ambience/drips.wav":   []byte("modded drips"),
+		})
+		p   = newPacker(t, tree, "pack.wad")
+		out = filepath.Join(t.TempDir(), "out")
+	)
+
+	require.Empty(t, p.Missing())
+	n, err := p.Write(out)
+	require.NoError(t, err)
+
+	files := readDir(t, out)
+	require.Len(t, files, n)
+	require.NotContains(t, files, "pack.wad")
+	require.NotContains(t, files, "models/tree.mdl", "identical to the base game")
+	require.Equal(t, "modded drips", string(files["sound/ambience/drips.wav"]))
+	require.Equal(t, "halflife.wad;test.wad", wadKey(t, filepath.Join(out, "maps", "room.bsp")))
+	require.Equal(t, "// generated by goldutil\nsound/ambience/drips.wav\n", string(files["maps/room.res"]))
+}
+
+func TestPackModWAD(t *testing.T) {
+	var (
+		tree = newTree(t, map[string][]byte{
+			"valve/halflife.wad":             newWAD(t, "OTHER"),
+			"valve/sound/ambience/drips.wav": []byte("drips"),
+			"mod/test.wad":                   newWAD(t, "WALL", "UNUSED"),
+			"mod/models/tree.mdl":            []byte("tree"),
+			"mod/models/treet.mdl":           []byte("tree textures"),
+			"mod/maps/room.txt":              []byte("briefing"),
+			"mod/sound/materials.txt":        []byte("P WALL\nM UNUSED\n"),
+		})
+		p   = newPacker(t, tree, "pack.wad")
+		out = filepath.Join(t.TempDir(), "out")
+	)
+
+	require.Empty(t, p.Missing())
+	_, err := p.Write(out)
+	require.NoError(t, err)
+
+	files := readDir(t, out)
+	require.ElementsMatch(t, []string{
+		"maps/room.bsp",
+		"maps/room.res",
+		"maps/room.txt",
+		"models/tree.mdl",
+		"models/treet.mdl",
+		"pack.wad",
+		"sound/materials.txt",
+	}, keys(files))
+	require.Equal(t, "pack.wad;halflife.wad", wadKey(t, filepath.Join(out, "maps", "room.bsp")))
+	require.Equal(t, "P WALL\n", string(files["sound/materials.txt"]))
+
+	packed, err := wad.NewFromFS(os.DirFS(out), "pack.wad")
+	require.NoError(t, err)
+	require.Equal(t, []string{"WALL"}, packed.Names())
+}
+
+func TestPackMissing(t *testing.T) {
+	for name, files := range map[string]map[string][]byte{
+		"texture": {
+			"valve/halflife.wad":             newWAD(t, "OTHER"),
+			"valve/test.wad":                 newWAD(t, "OTHER"),
+			"valve/models/tree.mdl":          []byte("tree"),
+			"valve/sound/ambience/drips.wav": []byte("drips"),
+		},
+		"file": {
+			"valve/halflife.wad":    newWAD(t, "OTHER"),
+			"valve/test.wad":        newWAD(t, "WALL"),
+			"valve/models/tree.mdl": []byte("tree"),
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var (
+				p   = newPacker(t, newTree(t, files), "pack.wad")
+				out = filepath.Join(t.TempDir(), "out")
+			)
+
+			missing := p.Missing()
+			require.Len(t, missing, 1)
+			require.Contains(t, missing[0], "missing "+name)
+
+			_, err := p.Write(out)
+			require.Error(t, err)
+			_, err = os.Stat(out)
+			require.ErrorIs(t, err, fs.ErrNotExist)
+		})
+	}
+}
+
+func TestPackZIP(t *testing.T) {
+	var (
+		tree = newTree(t, map[string][]byte{
+			"valve/halflife.wad":           newWAD(t, "OTHER"),
+			"mod/test.wad":                 newWAD(t, "WALL"),
+			"mod/models/tree.mdl":          []byte("tree"),
+			"mod/sound/ambience/drips.wav": []byte("drips"),
+		})
+		p   = newPacker(t, tree, "pack.wad")
+		dir = t.TempDir()
+	)
+
+	_, err := p.Write(filepath.Join(dir, "out"))
+	require.NoError(t, err)
+	n, err := p.Write(filepath.Join(dir, "out.zip"))
+	require.NoError(t, err)
+
+	zr, err := zip.OpenReader(filepath.Join(dir, "out.zip"))
+	require.NoError(t, err)
+	defer zr.Close() //nolint:errcheck // readonly
+
+	var (
+		expected = readDir(t, filepath.Join(dir, "out"))
+		actual   = map[string][]byte{}
+	)
+	for _, f := range zr.File {
+		data, err := fs.ReadFile(zr, f.Name)
+		require.NoError(t, err)
+		actual[f.Name] = data
+	}
+	require.Len(t, actual, n)
+	require.Equal(t, expected, actual)
+
+	_, err = p.Write(filepath.Join(dir, "out.zip"))
+	require.ErrorIs(t, err, fs.ErrExist)
+	_, err = p.Write(filepath.Join(dir, "out"))
+	require.ErrorIs(t, err, fs.ErrExist)
+}
+
+func keys(m map[string][]byte) []string {
+	ret := make([]string, 0, len(m))
+	for k := range m {
+		ret = append(ret, k)
+	}
+
+	return ret
+}
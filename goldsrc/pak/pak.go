@@ -0,0 +1,343 @@
+// Package pak implements Quake/GoldSrc PAK archives reading and writing.
+package pak
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Entry names are strings of 56 chars including the null terminator.
+const (
+	MaxNameLen = 55
+	NameSize   = MaxNameLen + 1
+)
+
+const (
+	HeaderSize = 12
+	EntrySize  = NameSize + 8
+)
+
+type Header struct {
+	MagicString [4]byte
+	DirOffset   int32
+	DirSize     int32
+}
+
+// Directory entry.
+type Entry struct {
+	Name   [NameSize]byte
+	Offset int32
+	Size   int32
+}
+
+func (e Entry) NameString() string {
+	if nul := bytes.IndexByte(e.Name[:], 0); nul >= 0 {
+		return string(e.Name[:nul])
+	}
+
+	return string(e.Name[:])
+}
+
+// PAK is a read-only fs.FS over the files of an archive, directories are
+// implied by the file names.
+type PAK struct {
+	r       io.ReaderAt
+	closer  io.Closer
+	entries []Entry
+	files   map[string]int           // name => entry index
+	dirs    map[string][]fs.DirEntry // name => sorted entries
+}
+
+var (
+	_ fs.ReadDirFS = (*PAK)(nil)
+	_ fs.StatFS    = (*PAK)(nil)
+)
+
+var magic = [4]byte{'P', 'A', 'C', 'K'}
+
+// Reads the directory of the archive of the given size, file data is read
+// from r on Open.
+func Read(r io.ReaderAt, size int64) (*PAK, error) {
+	var header Header
+	if err := binary.Read(io.NewSectionReader(r, 0, HeaderSize), binary.LittleEndian, &header); err != nil {
+		return nil, fmt.Errorf("unable to read header: %w", err)
+	}
+
+	if header.MagicString != magic {
+		return nil, errors.New("cannot find magic string, probably not a PAK file")
+	}
+
+	if header.DirOffset < HeaderSize || header.DirSize < 0 || header.DirSize%EntrySize != 0 ||
+		int64(header.DirOffset)+int64(header.DirSize) > size {
+		return nil, fmt.Errorf("invalid directory at offset %d of size %d", header.DirOffset, header.DirSize)
+	}
+
+	pak := PAK{
+		r:       r,
+		entries: make([]Entry, header.DirSize/EntrySize),
+		files:   make(map[string]int, header.DirSize/EntrySize),
+		dirs:    map[string][]fs.DirEntry{".": nil},
+	}
+
+	dir := io.NewSectionReader(r, int64(header.DirOffset), int64(header.DirSize))
+	if err := binary.Read(dir, binary.LittleEndian, &pak.entries); err != nil {
+		return nil, fmt.Errorf("unable to read directory: %w", err)
+	}
+
+	for i, entry := range pak.entries {
+		// Backslashes and colons would be separators and volumes on Windows.
+		name := entry.NameString()
+		if !fs.ValidPath(name) || name == "." || strings.ContainsAny(name, `\:`) {
+			return nil, fmt.Errorf("entry #%d has an invalid name: %s", i, name)
+		}
+		if _, ok := pak.files[name]; ok {
+			return nil, fmt.Errorf("entry #%d has a duplicated name: %s", i, name)
+		}
+		if _, ok := pak.dirs[name]; ok {
+			return nil, fmt.Errorf("entry #%d is both a file and a directory: %s", i, name)
+		}
+		if entry.Offset < 0 || entry.Size < 0 || int64(entry.Offset)+int64(entry.Size) > size {
+			return nil, fmt.Errorf("entry #%d has an invalid offset or size", i)
+		}
+
+		pak.files[name] = i
+		if err := pak.addToDir(name, fileInfo{name: path.Base(name), size: int64(entry.Size)}); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, entries := range pak.dirs {
+		slices.SortFunc(entries, func(a, b fs.DirEntry) int {
+			return strings.Compare(a.Name(), b.Name())
+		})
+	}
+
+	return &pak, nil
+}
+
+// Adds the file or directory to its parent directory, creating parents as
+// needed.
+func (pak *PAK) addToDir(name string, info fileInfo) error {
+	parent := path.Dir(name)
+	if _, ok := pak.files[parent]; ok {
+		return fmt.Errorf("%s is both a file and a directory", parent)
+	}
+
+	_, exists := pak.dirs[parent]
+	pak.dirs[parent] = append(pak.dirs[parent], fs.FileInfoToDirEntry(info))
+	if exists {
+		return nil
+	}
+
+	return pak.addToDir(parent, fileInfo{name: path.Base(parent), dir: true})
+}
+
+func NewFromFile(path string) (*PAK, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open file: %w", err)
+	}
+
+	stat, err := f.Stat()
+	if err != nil {
+		f.Close() //nolint:errcheck // in another error path already
+		return nil, fmt.Errorf("unable to stat file: %w", err)
+	}
+
+	pak, err := Read(f, stat.Size())
+	if err != nil {
+		f.Close() //nolint:errcheck // in another error path already
+		return nil, err
+	}
+	pak.closer = f
+
+	return pak, nil
+}
+
+// Closes the underlying file when created using NewFromFile.
+func (pak *PAK) Close() error {
+	if pak.closer == nil {
+		return nil
+	}
+
+	return pak.closer.Close()
+}
+
+// Returns the directory entries in archive order.
+func (pak *PAK) Entries() []Entry {
+	return slices.Clone(pak.entries)
+}
+
+func (pak *PAK) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	if i, ok := pak.files[name]; ok {
+		entry := pak.entries[i]
+		return &file{
+			SectionReader: io.NewSectionReader(pak.r, int64(entry.Offset), int64(entry.Size)),
+			info:          fileInfo{name: path.Base(name), size: int64(entry.Size)},
+		}, nil
+	}
+
+	if entries, ok := pak.dirs[name]; ok {
+		return &dir{info: fileInfo{name: path.Base(name), dir: true}, entries: entries}, nil
+	}
+
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+func (pak *PAK) Stat(name string) (fs.FileInfo, error) {
+	f, err := pak.Open(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: errors.Unwrap(err)}
+	}
+
+	return f.Stat()
+}
+
+func (pak *PAK) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, ok := pak.dirs[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+
+	return slices.Clone(entries), nil
+}
+
+// Writes an archive containing the given files read from fsys, in order.
+func Write(w io.Writer, fsys fs.FS, names []string) error {
+	var (
+		entries = make([]Entry, 0, len(names))
+		offset  = int64(HeaderSize)
+	)
+	for _, name := range names {
+		if len(name) > MaxNameLen {
+			return fmt.Errorf("name is too long, %d>%d: %s", len(name), MaxNameLen, name)
+		}
+
+		stat, err := fs.Stat(fsys, name)
+		if err != nil {
+			return fmt.Errorf("unable to stat '%s': %w", name, err)
+		}
+		if !stat.Mode().IsRegular() {
+			return fmt.Errorf("'%s' is not a regular file", name)
+		}
+
+		entry := Entry{Offset: int32(offset), Size: int32(stat.Size())}
+		copy(entry.Name[:], name)
+		entries = append(entries, entry)
+
+		offset += stat.Size()
+		if offset > 1<<31-1 {
+			return errors.New("archive is over 2GiB")
+		}
+	}
+
+	header := Header{
+		MagicString: magic,
+		DirOffset:   int32(offset),
+		DirSize:     int32(len(entries) * EntrySize),
+	}
+	if err := binary.Write(w, binary.LittleEndian, header); err != nil {
+		return fmt.Errorf("unable to write header: %w", err)
+	}
+
+	for i, entry := range entries {
+		if err := copyFile(w, fsys, names[i], entry.Size); err != nil {
+			return err
+		}
+	}
+
+	if err := binary.Write(w, binary.LittleEndian, entries); err != nil {
+		return fmt.Errorf("unable to write directory: %w", err)
+	}
+
+	return nil
+}
+
+func copyFile(w io.Writer, fsys fs.FS, name string, size int32) error {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return fmt.Errorf("unable to open '%s': %w", name, err)
+	}
+	defer f.Close() //nolint:errcheck // readonly
+
+	n, err := io.Copy(w, io.LimitReader(f, int64(size)))
+	if err != nil {
+		return fmt.Errorf("unable to write '%s': %w", name, err)
+	}
+	if n != int64(size) {
+		return fmt.Errorf("'%s' changed size while writing", name)
+	}
+
+	return nil
+}
+
+type fileInfo struct {
+	name string
+	size int64
+	dir  bool
+}
+
+func (fi fileInfo) Name() string       { return fi.name }
+func (fi fileInfo) Size() int64        { return fi.size }
+func (fi fileInfo) ModTime() time.Time { return time.Time{} }
+func (fi fileInfo) IsDir() bool        { return fi.dir }
+func (fi fileInfo) Sys() any           { return nil }
+
+func (fi fileInfo) Mode() fs.FileMode {
+	if fi.dir {
+		return fs.ModeDir | 0o555
+	}
+
+	return 0o444
+}
+
+type file struct {
+	*io.SectionReader
+	info fileInfo
+}
+
+func (f *file) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *file) Close() error               { return nil }
+
+type dir struct {
+	info    fileInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *dir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *dir) Close() error               { return nil }
+
+func (d *dir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: errors.New("is a directory")}
+}
+
+func (d *dir) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return rest, nil
+	}
+
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+
+	n = min(n, len(rest))
+	d.offset += n
+
+	return rest[:n], nil
+}
@@ -0,0 +1,81 @@
+package pak_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/fs"
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/L-P/goldutil/goldsrc/pak"
+)
+
+func TestRoundTrip(t *testing.T) {
+	input := fstest.MapFS{
+		"maps/test.bsp":       {Data: []byte("bsp")},
+		"sound/a/b.wav":       {Data: []byte("wav")},
+		"titles.txt":          {Data: []byte("titles")},
+		"sound/materials.txt": {Data: []byte{}},
+	}
+	names := []string{"titles.txt", "maps/test.bsp", "sound/a/b.wav", "sound/materials.txt"}
+
+	var b bytes.Buffer
+	require.NoError(t, pak.Write(&b, input, names))
+	require.Equal(t, pak.HeaderSize+3+3+6+len(names)*pak.EntrySize, b.Len())
+
+	archive, err := pak.Read(bytes.NewReader(b.Bytes()), int64(b.Len()))
+	require.NoError(t, err)
+
+	entries := archive.Entries()
+	require.Len(t, entries, len(names))
+	for i, v := range entries {
+		require.Equal(t, names[i], v.NameString())
+	}
+
+	data, err := fs.ReadFile(archive, "sound/a/b.wav")
+	require.NoError(t, err)
+	require.Equal(t, "wav", string(data))
+
+	require.NoError(t, fstest.TestFS(archive, names...))
+
+	_, err = archive.Open("missing.txt")
+	require.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestWriteErrors(t *testing.T) {
+	long := "sound/" + string(bytes.Repeat([]byte{'a'}, pak.MaxNameLen)) + ".wav"
+	input := fstest.MapFS{long: {Data: []byte{}}}
+	require.ErrorContains(t, pak.Write(&bytes.Buffer{}, input, []string{long}), "name is too long")
+	require.ErrorIs(t, pak.Write(&bytes.Buffer{}, input, []string{"missing"}), fs.ErrNotExist)
+}
+
+func TestReadErrors(t *testing.T) {
+	_, err := pak.Read(bytes.NewReader([]byte("WAD3\x00\x00\x00\x00\x00\x00\x00\x00")), pak.HeaderSize)
+	require.ErrorContains(t, err, "not a PAK file")
+
+	var b bytes.Buffer
+	require.NoError(t, pak.Write(&b, fstest.MapFS{"a": {Data: []byte("x")}}, []string{"a"}))
+	data := b.Bytes()
+	for _, name := range []string{"../a", `..\a`, "C:a", "/a"} {
+		renamed := slices.Clone(data)
+		copy(renamed[pak.HeaderSize+1:], name+"\x00")
+		_, err = pak.Read(bytes.NewReader(renamed), int64(len(renamed)))
+		require.ErrorContains(t, err, "invalid name", name)
+	}
+
+	// A directory past the end of the file must not be allocated.
+	var header pak.Header
+	require.NoError(t, binary.Read(bytes.NewReader(data), binary.LittleEndian, &header))
+	header.DirSize = (1<<31 - 1) / pak.EntrySize * pak.EntrySize
+	var corrupt bytes.Buffer
+	require.NoError(t, binary.Write(&corrupt, binary.LittleEndian, header))
+	corrupt.Write(data[pak.HeaderSize:])
+	_, err = pak.Read(bytes.NewReader(corrupt.Bytes()), int64(corrupt.Len()))
+	require.ErrorContains(t, err, "invalid directory")
+
+	_, err = pak.Read(bytes.NewReader(data), int64(len(data)-1))
+	require.ErrorContains(t, err, "invalid directory")
+}
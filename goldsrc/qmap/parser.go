@@ -25,6 +25,7 @@ type parser struct {
 	qm      *QMap
 
 	curEntity *AnonymousEntity
+	curKeys   []string
 	curBrush  Brush
 }
 
@@ -78,12 +79,17 @@ func (p *parser) run() (*QMap, error) {
 }
 
 func (p *parser) parseOutside(line string, lineNumber int) (parserState, error) {
+	if strings.TrimSpace(line) == "" {
+		return psOutside, nil
+	}
+
 	if line != "{" {
 		return psNone, ParseError{"expected start of entity", lineNumber, line}
 	}
 
 	newEnt := NewAnonymousEntity()
 	p.curEntity = &newEnt
+	p.curKeys = nil
 
 	return psInEntity, nil
 }
@@ -98,6 +104,7 @@ func (p *parser) parseEntity(line string, lineNumber int) (parserState, error) {
 
 		p.qm.entities[index] = *p.curEntity
 		p.qm.order = append(p.qm.order, index)
+		p.qm.keyOrder[index] = p.curKeys
 		p.curEntity = nil
 
 		return psOutside, nil
@@ -114,6 +121,9 @@ func (p *parser) parseEntity(line string, lineNumber int) (parserState, error) {
 
 	// Only keep last value.
 	// TODO: Double-check that it's what the engine does.
+	if _, ok := p.curEntity.KVs[pKey]; !ok {
+		p.curKeys = append(p.curKeys, pKey)
+	}
 	p.curEntity.KVs[pKey] = pValue
 
 	return psInEntity, nil
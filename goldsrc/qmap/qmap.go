@@ -3,10 +3,12 @@
 package qmap
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"iter"
 	"os"
+	"sort"
 	"strings"
 
 	"github.com/google/uuid"
@@ -22,6 +24,10 @@ import (
 type QMap struct {
 	entities map[uuid.UUID]AnonymousEntity
 	order    []uuid.UUID
+
+	// Original KV order of parsed entities, the engine and some entities
+	// (multi_manager) care about it.
+	keyOrder map[uuid.UUID][]string
 }
 
 // Returns an iterator over the entities in their original order.
@@ -40,6 +46,7 @@ func (qm *QMap) Entities() iter.Seq[AnonymousEntity] {
 func New() *QMap {
 	return &QMap{
 		entities: make(map[uuid.UUID]AnonymousEntity),
+		keyOrder: make(map[uuid.UUID][]string),
 	}
 }
 
@@ -166,4 +173,63 @@ func (qm *QMap) AddAnonymousEntities(ents ...AnonymousEntity) error {
 
 func (qm *QMap) Delete(index uuid.UUID) {
 	delete(qm.entities, index)
+	delete(qm.keyOrder, index)
+}
+
+// Returns the keys of an entity, keys that were present when the map was
+// parsed come first in their original order, the others follow sorted.
+func (qm *QMap) orderedKeys(index uuid.UUID) []string {
+	var (
+		ent  = qm.entities[index]
+		ret  = make([]string, 0, len(ent.KVs))
+		seen = make(map[string]struct{}, len(ent.KVs))
+	)
+
+	for _, k := range qm.keyOrder[index] {
+		if _, ok := ent.KVs[k]; ok {
+			ret = append(ret, k)
+			seen[k] = struct{}{}
+		}
+	}
+
+	var added []string
+	for k := range ent.KVs {
+		if _, ok := seen[k]; !ok {
+			added = append(added, k)
+		}
+	}
+	sort.Strings(added)
+
+	return append(ret, added...)
+}
+
+// Marshals the entities in the format used by the BSP entity lump and
+// ripent, without comments and brushes.
+func (qm *QMap) MarshalEntities() ([]byte, error) {
+	var b bytes.Buffer
+
+	for _, index := range qm.order {
+		ent, ok := qm.entities[index]
+		if !ok {
+			continue
+		}
+
+		if len(ent.Brushes) > 0 {
+			return nil, fmt.Errorf("entity %s has brushes", ent.KVs["classname"])
+		}
+
+		b.WriteString("{\n")
+		for _, k := range qm.orderedKeys(index) {
+			v := ent.KVs[k]
+			if strings.Contains(k, `"`) || strings.Contains(v, `"`) {
+				return nil, fmt.Errorf("property cannot contain double-quotes: %s %s", k, v)
+			}
+
+			fmt.Fprintf(&b, `"%s" "%s"`, k, v)
+			b.WriteRune('\n')
+		}
+		b.WriteString("}\n")
+	}
+
+	return b.Bytes(), nil
 }
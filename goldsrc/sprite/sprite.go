@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/L-P/goldutil/palette"
 	"io"
+	"io/fs"
 	"math"
 	"os"
 	"strings"
@@ -94,6 +95,17 @@ func NewFromFile(path string) (Sprite, error) {
 	return NewFromReader(f)
 }
 
+// Reads a sprite from a filesystem, eg. a mod filesystem (see modfs).
+func NewFromFS(fsys fs.FS, name string) (Sprite, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return Sprite{}, fmt.Errorf("unable to open file: %w", err)
+	}
+	defer f.Close() //nolint:errcheck // readonly
+
+	return NewFromReader(f)
+}
+
 func (spr *Sprite) AddFrame(frame Frame) {
 	spr.Frames = append(spr.Frames, frame)
 	spr.NumFrames++
@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"io"
 	"io/fs"
-	"os"
 	"strconv"
 	"strings"
 )
@@ -40,14 +39,13 @@ func NewTitlesFromReader(r io.Reader) (map[string]Title, error) {
 	return parser.run()
 }
 
-func NewTitlesFromModRoot(mod *os.Root) (map[string]Title, error) {
-	// That's a normal situation, there's just no titles for the current mod.
-	if _, err := mod.Stat("titles.txt"); errors.Is(err, fs.ErrNotExist) {
-		return make(map[string]Title), nil
-	}
-
+// Reads titles.txt from the root of a mod filesystem (see modfs).
+func NewTitlesFromFS(mod fs.FS) (map[string]Title, error) {
 	f, err := mod.Open("titles.txt")
-	if err != nil {
+	if errors.Is(err, fs.ErrNotExist) {
+		// That's a normal situation, there's just no titles for the current mod.
+		return make(map[string]Title), nil
+	} else if err != nil {
 		return nil, fmt.Errorf("unable to open titles.txt for reading: %w", err)
 	}
 	defer f.Close() //nolint:errcheck // readonly
@@ -0,0 +1,35 @@
+package wad
+
+import "strings"
+
+// Collection is a list of WADs searched in order, the way the engine searches
+// the WADs listed in the worldspawn "wad" key.
+type Collection []WAD
+
+// Returns the first texture matching the given name, ignoring case.
+func (c Collection) GetTexture(name string) (MIPTexture, bool) {
+	for i := range c {
+		if tex, ok := c[i].getTextureFold(name); ok {
+			return tex, true
+		}
+	}
+
+	return MIPTexture{}, false
+}
+
+func (wad *WAD) getTextureFold(name string) (MIPTexture, bool) {
+	// Fast path, halflife.wad entries are uppercase.
+	for _, v := range []string{name, strings.ToUpper(name), strings.ToLower(name)} {
+		if tex, ok := wad.GetTexture(v); ok {
+			return tex, true
+		}
+	}
+
+	for i := range wad.textures {
+		if strings.EqualFold(wad.textures[i].entry.Name.String(), name) {
+			return wad.textures[i].mip, true
+		}
+	}
+
+	return MIPTexture{}, false
+}
@@ -96,6 +96,37 @@ func (mip *MIPTexture) String() string {
 }
 
 func (mip *MIPTexture) Read(r io.ReadSeeker, offset int32) error {
+	if err := mip.readMIPData(r, offset); err != nil {
+		return err
+	}
+
+	if !mip.IsEmbedded() {
+		return nil
+	}
+
+	if err := binary.Read(r, binary.LittleEndian, &mip.PaletteSize); err != nil {
+		return fmt.Errorf("unable to read PaletteSize: %w", err)
+	}
+
+	paletteOffset := int64(offset + mip.Size() - MIPPaletteDataSize - 2)
+	if _, err := r.Seek(paletteOffset, io.SeekStart); err != nil {
+		return fmt.Errorf("unable to seek to palette data offset 0x%x: %w", paletteOffset, err)
+	}
+
+	if err := binary.Read(r, binary.LittleEndian, &mip.Palette); err != nil {
+		return fmt.Errorf("unable to read Palette: %w", err)
+	}
+
+	return nil
+}
+
+// Reads a Quake texture, Quake textures have no palette and use the
+// palette of the game instead.
+func (mip *MIPTexture) ReadPaletteless(r io.ReadSeeker, offset int32) error {
+	return mip.readMIPData(r, offset)
+}
+
+func (mip *MIPTexture) readMIPData(r io.ReadSeeker, offset int32) error {
 	if _, err := r.Seek(int64(offset), io.SeekStart); err != nil {
 		return fmt.Errorf("unable to seek to offset %x of MIPTexture header", offset)
 	}
@@ -124,19 +155,6 @@ func (mip *MIPTexture) Read(r io.ReadSeeker, offset int32) error {
 		}
 	}
 
-	if err := binary.Read(r, binary.LittleEndian, &mip.PaletteSize); err != nil {
-		return fmt.Errorf("unable to read PaletteSize: %w", err)
-	}
-
-	paletteOffset := int64(offset + mip.Size() - MIPPaletteDataSize - 2)
-	if _, err := r.Seek(paletteOffset, io.SeekStart); err != nil {
-		return fmt.Errorf("unable to seek to palette data offset 0x%x: %w", paletteOffset, err)
-	}
-
-	if err := binary.Read(r, binary.LittleEndian, &mip.Palette); err != nil {
-		return fmt.Errorf("unable to read Palette: %w", err)
-	}
-
 	return nil
 }
 
@@ -170,14 +188,8 @@ func (mip *MIPTexture) SetData(pix []byte) error {
 }
 
 func (mip *MIPTexture) Write(w io.Writer) (int, error) {
-	if err := binary.Write(w, binary.LittleEndian, mip.MIPTextureHeader); err != nil {
-		return 0, fmt.Errorf("unable to write MIPTextureHeader: %w", err)
-	}
-
-	for mipID := range mip.MIPData {
-		if err := binary.Write(w, binary.LittleEndian, mip.MIPData[mipID]); err != nil {
-			return 0, fmt.Errorf("unable to write mip data #%d: %w", mipID, err)
-		}
+	if _, err := mip.WritePaletteless(w); err != nil {
+		return 0, err
 	}
 
 	if err := binary.Write(w, binary.LittleEndian, mip.PaletteSize); err != nil {
@@ -194,3 +206,23 @@ func (mip *MIPTexture) Write(w io.Writer) (int, error) {
 
 	return int(mip.Size()), nil
 }
+
+// Writes the texture the way Quake does, without a palette.
+func (mip *MIPTexture) WritePaletteless(w io.Writer) (int, error) {
+	if err := binary.Write(w, binary.LittleEndian, mip.MIPTextureHeader); err != nil {
+		return 0, fmt.Errorf("unable to write MIPTextureHeader: %w", err)
+	}
+
+	for mipID := range mip.MIPData {
+		if err := binary.Write(w, binary.LittleEndian, mip.MIPData[mipID]); err != nil {
+			return 0, fmt.Errorf("unable to write mip data #%d: %w", mipID, err)
+		}
+	}
+
+	return int(mip.PalettelessSize()), nil
+}
+
+// Size of the texture written without a palette.
+func (mip *MIPTexture) PalettelessSize() int32 {
+	return mip.Size() - 2 - 2 - MIPPaletteDataSize
+}
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"strings"
 	"unsafe"
@@ -245,17 +246,33 @@ func NewFromFile(path string) (WAD, error) {
 	return wad, nil
 }
 
-func (wad *WAD) AddTexture(mip MIPTexture) error {
-	if _, ok := wad.nameToTextureIndex[mip.Name.String()]; ok {
-		return fmt.Errorf("a texture with this name already exists in the wad: %s", mip.Name.String())
+// Reads a WAD from a filesystem, eg. a mod filesystem (see modfs).
+func NewFromFS(fsys fs.FS, name string) (WAD, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return WAD{}, fmt.Errorf("unable to read file: %w", err)
+	}
+
+	var wad WAD
+	if err := wad.Read(bytes.NewReader(data)); err != nil {
+		return WAD{}, err
 	}
 
+	return wad, nil
+}
+
+func (wad *WAD) AddTexture(mip MIPTexture) error {
 	// Lump names are lowercase, entry names are uppercase, cf. halflife.wad.
 	entryName, err := NewTextureName(strings.ToUpper(mip.Name.String()))
 	if err != nil {
 		return fmt.Errorf("invalid entry name '%s': %w", entryName.String(), err)
 	}
 
+	// Indexed by entry name like when reading a WAD.
+	if _, ok := wad.nameToTextureIndex[entryName.String()]; ok {
+		return fmt.Errorf("a texture with this name already exists in the wad: %s", mip.Name.String())
+	}
+
 	size := mip.Size()
 	var entry = Entry{
 		Size:             size,
@@ -265,7 +282,7 @@ func (wad *WAD) AddTexture(mip MIPTexture) error {
 	}
 
 	wad.textures = append(wad.textures, texture{entry: entry, mip: mip})
-	wad.nameToTextureIndex[mip.Name.String()] = len(wad.textures) - 1
+	wad.nameToTextureIndex[entryName.String()] = len(wad.textures) - 1
 
 	return nil
 }
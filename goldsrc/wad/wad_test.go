@@ -0,0 +1,27 @@
+package wad_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/L-P/goldutil/goldsrc/wad"
+)
+
+func TestAddTexture(t *testing.T) {
+	w := wad.New()
+	tex, err := wad.NewMIPTexture("{Grate", 16, 16)
+	require.NoError(t, err)
+	require.NoError(t, tex.SetData(make([]byte, 16*16)))
+	require.NoError(t, w.AddTexture(tex))
+	require.ErrorContains(t, w.AddTexture(tex), "already exists")
+
+	require.Equal(t, []string{"{GRATE"}, w.Names())
+	actual, ok := w.GetTexture(w.Names()[0])
+	require.True(t, ok)
+	require.Equal(t, "{grate", actual.Name.String())
+
+	actual, ok = wad.Collection{wad.New(), w}.GetTexture("{gRaTe")
+	require.True(t, ok)
+	require.Equal(t, tex, actual)
+}
@@ -0,0 +1,203 @@
+// Package bsptest builds small BSPs for tests.
+package bsptest
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/L-P/goldutil/goldsrc/bsp"
+	"github.com/L-P/goldutil/goldsrc/wad"
+)
+
+// Size of the room built by NewRoom.
+const RoomSize = 256
+
+// Hull sizes as defined in the game DLL, hull 0 is a point.
+var Hulls = [bsp.MaxMapHulls][2]bsp.Vec3f{
+	{},
+	{{X: -16, Y: -16, Z: -36}, {X: 16, Y: 16, Z: 36}},
+	{{X: -32, Y: -32, Z: -32}, {X: 32, Y: 32, Z: 32}},
+	{{X: -16, Y: -16, Z: -18}, {X: 16, Y: 16, Z: 18}},
+}
+
+// Creates a sealed cubic room of RoomSize units going from the origin
+// toward positive coordinates. Everything outside is solid.
+//
+//nolint:funlen // it's a BSP compiler in a function
+func NewRoom(t testing.TB) *bsp.BSP {
+	t.Helper()
+
+	var (
+		ret      bsp.BSP
+		vertices = map[bsp.Vec3f]uint16{}
+		size     = float32(RoomSize)
+		center   = bsp.Vec3f{X: size / 2, Y: size / 2, Z: size / 2}
+		axes     = []bsp.Vec3f{{X: 1}, {Y: 1}, {Z: 1}}
+	)
+
+	ret.Entities = bsp.RawLump("{\n" +
+		`"classname" "worldspawn"` + "\n" +
+		`"wad" "\half-life\valve\halflife.wad;test.wad"` + "\n" +
+		"}\n{\n" +
+		`"classname" "info_player_start"` + "\n" +
+		`"origin" "128 128 36"` + "\n" +
+		"}\n\x00")
+
+	// An embedded texture for the floor and ceiling, walls use one from test.wad.
+	floor, err := wad.NewMIPTexture("floor", 16, 16)
+	require.NoError(t, err)
+	require.NoError(t, floor.SetData(make([]byte, 16*16)))
+	wallName, err := wad.NewTextureName("wall")
+	require.NoError(t, err)
+	wall := wad.MIPTexture{MIPTextureHeader: wad.MIPTextureHeader{Name: wallName, Width: 64, Height: 64}}
+	ret.Textures.Textures = []wad.MIPTexture{floor, wall}
+
+	ret.TexInfo = bsp.TexInfoLump{
+		{S: bsp.TexAxis{Vec: bsp.Vec3f{Y: 1}}, T: bsp.TexAxis{Vec: bsp.Vec3f{Z: -1}}, MIPTex: 1},
+		{S: bsp.TexAxis{Vec: bsp.Vec3f{X: 1}}, T: bsp.TexAxis{Vec: bsp.Vec3f{Z: -1}}, MIPTex: 1},
+		{S: bsp.TexAxis{Vec: bsp.Vec3f{X: 1}}, T: bsp.TexAxis{Vec: bsp.Vec3f{Y: -1}}},
+	}
+
+	// Planes, faces, and nodes, two per axis. Nodes are chained through their
+	// front (for the min plane) or back (for the max plane) child, the last
+	// one leads to the only empty leaf.
+	ret.Edges = bsp.EdgeLump{{}} // edge 0 is never used
+	for axis, normal := range axes {
+		for side := range 2 {
+			var (
+				index = axis*2 + side
+				dist  = float32(side) * size
+				child = int16(index + 1)
+			)
+			if index == 5 {
+				child = -2 // leaf 1
+			}
+
+			ret.Planes = append(ret.Planes, bsp.Plane{Normal: normal, Dist: dist, Type: bsp.PlaneType(axis)})
+			node := bsp.Node{
+				Plane:     int32(index),
+				Children:  [2]int16{child, -1},
+				Maxs:      bsp.Vec3s{RoomSize, RoomSize, RoomSize},
+				FirstFace: uint16(index),
+				NumFaces:  1,
+			}
+			if side == 1 {
+				node.Children[0], node.Children[1] = node.Children[1], node.Children[0]
+			}
+			ret.Nodes = append(ret.Nodes, node)
+
+			// Faces point inside the room, clockwise when seen from the front.
+			var (
+				facing = normal
+				up     = bsp.Vec3f{Z: 1}
+			)
+			if side == 1 {
+				facing = normal.Scale(-1)
+			}
+			if axis == 2 {
+				up = bsp.Vec3f{Y: 1}
+			}
+			var (
+				right   = facing.Scale(-1).Cross(up).Scale(size / 2)
+				origin  = center.Add(normal.Scale(dist - size/2))
+				corners = []bsp.Vec3f{
+					origin.Sub(right).Sub(up.Scale(size / 2)),
+					origin.Sub(right).Add(up.Scale(size / 2)),
+					origin.Add(right).Add(up.Scale(size / 2)),
+					origin.Add(right).Sub(up.Scale(size / 2)),
+				}
+			)
+
+			var firstEdge = len(ret.SurfEdges)
+			for i := range corners {
+				ret.SurfEdges = append(ret.SurfEdges, int32(len(ret.Edges)))
+				ret.Edges = append(ret.Edges, bsp.Edge{
+					vertexIndex(&ret, vertices, corners[i]),
+					vertexIndex(&ret, vertices, corners[(i+1)%len(corners)]),
+				})
+			}
+
+			const luxels = (RoomSize/16 + 1) * (RoomSize/16 + 1)
+			ret.Faces = append(ret.Faces, bsp.Face{
+				Plane:       uint16(index),
+				Side:        int16(side),
+				FirstEdge:   int32(firstEdge),
+				NumEdges:    int16(len(corners)),
+				TexInfo:     int16(axis),
+				Styles:      [bsp.MaxLightmaps]uint8{0, 255, 255, 255},
+				LightOffset: int32(len(ret.Lighting)),
+			})
+			ret.Lighting = append(ret.Lighting, bytes.Repeat([]byte{byte(index * 40)}, luxels*3)...)
+			ret.MarkSurfaces = append(ret.MarkSurfaces, uint16(index))
+		}
+	}
+
+	ret.Leaves = bsp.LeafLump{
+		{Contents: bsp.ContentsSolid, VisOffset: -1},
+		{
+			Contents:        bsp.ContentsEmpty,
+			Maxs:            bsp.Vec3s{RoomSize, RoomSize, RoomSize},
+			NumMarkSurfaces: uint16(len(ret.MarkSurfaces)),
+		},
+	}
+	ret.Visibility = bsp.RawLump{0x01}
+
+	world := bsp.Model{
+		Maxs:     bsp.Vec3f{X: size, Y: size, Z: size},
+		VisLeafs: 1,
+		NumFaces: int32(len(ret.Faces)),
+	}
+
+	// Clip hulls use the same room shrunk by the hull size.
+	for hull := 1; hull < bsp.MaxMapHulls; hull++ {
+		world.HeadNodes[hull] = int32(len(ret.ClipNodes))
+		for axis, normal := range axes {
+			mins := -Hulls[hull][0].Dot(normal)
+			maxs := size - Hulls[hull][1].Dot(normal)
+			ret.ClipNodes = append(ret.ClipNodes, bsp.ClipNode{
+				Plane:    int32(len(ret.Planes)),
+				Children: [2]int16{int16(len(ret.ClipNodes) + 1), int16(bsp.ContentsSolid)},
+			}, bsp.ClipNode{
+				Plane:    int32(len(ret.Planes) + 1),
+				Children: [2]int16{int16(bsp.ContentsSolid), int16(len(ret.ClipNodes) + 2)},
+			})
+			ret.Planes = append(ret.Planes,
+				bsp.Plane{Normal: normal, Dist: mins, Type: bsp.PlaneType(axis)},
+				bsp.Plane{Normal: normal, Dist: maxs, Type: bsp.PlaneType(axis)},
+			)
+		}
+		ret.ClipNodes[len(ret.ClipNodes)-1].Children[1] = int16(bsp.ContentsEmpty)
+	}
+
+	ret.Models = bsp.ModelLump{world}
+
+	return &ret
+}
+
+func vertexIndex(b *bsp.BSP, known map[bsp.Vec3f]uint16, v bsp.Vec3f) uint16 {
+	if i, ok := known[v]; ok {
+		return i
+	}
+
+	known[v] = uint16(len(b.Vertices))
+	b.Vertices = append(b.Vertices, v)
+
+	return known[v]
+}
+
+// Writes the BSP to a temporary file and returns its path and contents.
+func Write(t testing.TB, b *bsp.BSP) (string, []byte) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "test.bsp")
+	require.NoError(t, b.WriteToFile(path))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	return path, data
+}
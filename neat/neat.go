@@ -4,7 +4,7 @@ package neat
 import (
 	_ "embed"
 	"fmt"
-	"os"
+	"io/fs"
 	"strings"
 
 	"github.com/google/uuid"
@@ -17,7 +17,7 @@ import (
 //go:embed goldutil.fgd
 var FGD string
 
-func Neatify(qm *qmap.QMap, mod *os.Root) error {
+func Neatify(qm *qmap.QMap, mod fs.FS) error {
 	if err := handleMasters(qm); err != nil {
 		return fmt.Errorf("unable to handle neat_master: %w", err)
 	}
@@ -112,13 +112,13 @@ func getMasterAdditions(master Master) []any {
 	}
 }
 
-func handleMessages(qm *qmap.QMap, mod *os.Root) error {
+func handleMessages(qm *qmap.QMap, mod fs.FS) error {
 	messages, err := qmap.FindByKV[Message](qm, "classname", "neat_message")
 	if err != nil {
 		return fmt.Errorf("unable to obtain neat_message entitites: %w", err)
 	}
 
-	titles, err := goldsrc.NewTitlesFromModRoot(mod)
+	titles, err := goldsrc.NewTitlesFromFS(mod)
 	if err != nil {
 		return fmt.Errorf("unable to parse titles.txt: %w", err)
 	}
@@ -3,7 +3,6 @@ package neat_test
 import (
 	"embed"
 	"io/fs"
-	"os"
 	"slices"
 	"strings"
 	"testing"
@@ -14,7 +13,7 @@ import (
 	"github.com/L-P/goldutil/neat"
 )
 
-//go:embed test_cases/*.map
+//go:embed test_cases/*.map test_cases/titles.txt
 var cases embed.FS
 
 func TestNeatify(t *testing.T) {
@@ -32,7 +31,7 @@ func TestNeatify(t *testing.T) {
 			qm, err := qmap.LoadFromReader(input)
 			require.NoError(t, err)
 
-			mod, err := os.OpenRoot("test_cases")
+			mod, err := fs.Sub(cases, "test_cases")
 			require.NoError(t, err)
 
 			require.NoError(t, neat.Neatify(qm, mod))